@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/crypto"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage basic authentication users",
+	Long: `Manage the users in the project's bcrypt htpasswd file (auth/htpasswd).
+
+The file is mounted into the container and used by locations protected with
+security.basic_auth or a service's basic_auth setting.
+
+Examples:
+  keynginx auth add-user alice
+  keynginx auth add-user ci --password "$PREVIEW_PASSWORD"
+  keynginx auth remove-user alice
+  keynginx auth list`,
+}
+
+var (
+	authProject  string
+	authPassword string
+)
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+
+	authCmd.PersistentFlags().StringVarP(&authProject, "project", "p", ".", "Project directory path")
+
+	addUserCmd := &cobra.Command{
+		Use:   "add-user <username>",
+		Short: "Add a user or change their password",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAuthAddUser,
+	}
+	addUserCmd.Flags().StringVar(&authPassword, "password", "", "Password (prompted for when omitted)")
+	authCmd.AddCommand(addUserCmd)
+
+	authCmd.AddCommand(&cobra.Command{
+		Use:   "remove-user <username>",
+		Short: "Remove a user",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAuthRemoveUser,
+	})
+
+	authCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List users",
+		Args:  cobra.NoArgs,
+		RunE:  runAuthList,
+	})
+}
+
+func runAuthAddUser(cmd *cobra.Command, args []string) error {
+	htpasswd, cfg, err := loadProjectHtpasswd()
+	if err != nil {
+		return err
+	}
+
+	password := authPassword
+	if password == "" {
+		if password, err = promptPassword(); err != nil {
+			return err
+		}
+	}
+
+	existing := htpasswd.Has(args[0])
+	if err := htpasswd.SetPassword(args[0], password); err != nil {
+		return err
+	}
+	if err := htpasswd.Save(); err != nil {
+		return err
+	}
+
+	if existing {
+		fmt.Printf("✅ Updated password for %s\n", args[0])
+	} else {
+		fmt.Printf("✅ Added user %s\n", args[0])
+	}
+
+	if !cfg.BasicAuthUsed() {
+		fmt.Println("💡 Enable it with 'keynginx config set security.basic_auth.enabled true'")
+	}
+	return nil
+}
+
+func runAuthRemoveUser(cmd *cobra.Command, args []string) error {
+	htpasswd, _, err := loadProjectHtpasswd()
+	if err != nil {
+		return err
+	}
+
+	if !htpasswd.Remove(args[0]) {
+		return fmt.Errorf("user %s not found", args[0])
+	}
+	if err := htpasswd.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Removed user %s\n", args[0])
+	return nil
+}
+
+func runAuthList(cmd *cobra.Command, args []string) error {
+	htpasswd, _, err := loadProjectHtpasswd()
+	if err != nil {
+		return err
+	}
+
+	users := htpasswd.Users()
+	if len(users) == 0 {
+		fmt.Println("No users configured")
+		return nil
+	}
+	for _, user := range users {
+		fmt.Printf("   • %s\n", user)
+	}
+	return nil
+}
+
+func loadProjectHtpasswd() (*crypto.Htpasswd, *config.Config, error) {
+	cfg, err := loadProjectConfig(authProject)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load project configuration: %w", err)
+	}
+
+	htpasswd, err := crypto.LoadHtpasswd(filepath.Join(cfg.Project.OutputDir, config.HtpasswdFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	return htpasswd, cfg, nil
+}
+
+func promptPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		// Piped input: echo "$PASSWORD" | keynginx auth add-user ci
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	fmt.Print("Confirm password: ")
+	confirm, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	if string(password) != string(confirm) {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return string(password), nil
+}
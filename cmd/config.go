@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and edit keynginx.yaml",
+	Long: `View and edit keynginx.yaml without losing comments or key order.
+
+Keys use dotted paths. List items are addressed by name or index:
+  nginx.https_port
+  nginx.services.backend.path
+  security.custom_headers.X-Version
+
+With --env, edits are written to the keynginx.<env>.yaml overlay instead of
+the base file, and 'get' shows the merged value.
+
+Examples:
+  keynginx config get nginx.services
+  keynginx config set nginx.https_port 9443
+  keynginx config add-service api 8000 /api
+  keynginx config remove-service api
+  keynginx config set-header X-Version 2.0
+  keynginx config set-security-level strict --env prod
+  keynginx config schema --install
+  keynginx config validate --env prod`,
+}
+
+var (
+	configProject        string
+	configSecurityHeader bool
+	configSchemaOutput   string
+	configSchemaInstall  bool
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	configCmd.PersistentFlags().StringVarP(&configProject, "project", "p", ".", "Project directory path")
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "get [key]",
+		Short: "Print a configuration value",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runConfigGet,
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a configuration value",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runConfigSet,
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "add-service <name> <port> [path]",
+		Short: "Add a proxied service",
+		Args:  cobra.RangeArgs(2, 3),
+		RunE:  runConfigAddService,
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "remove-service <name>",
+		Short: "Remove a proxied service",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runConfigRemoveService,
+	})
+
+	setHeaderCmd := &cobra.Command{
+		Use:   "set-header <name> <value>",
+		Short: "Add or update a custom response header",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runConfigSetHeader,
+	}
+	setHeaderCmd.Flags().BoolVar(&configSecurityHeader, "security", false, "Edit security.custom_headers instead of nginx.custom_headers")
+	configCmd.AddCommand(setHeaderCmd)
+
+	removeHeaderCmd := &cobra.Command{
+		Use:   "remove-header <name>",
+		Short: "Remove a custom response header",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runConfigRemoveHeader,
+	}
+	removeHeaderCmd.Flags().BoolVar(&configSecurityHeader, "security", false, "Edit security.custom_headers instead of nginx.custom_headers")
+	configCmd.AddCommand(removeHeaderCmd)
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "set-security-level <strict|balanced|permissive>",
+		Short: "Change the security level and its HSTS/CSP defaults",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runConfigSetSecurityLevel,
+	})
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for keynginx.yaml",
+		Long: `Print the JSON Schema for keynginx.yaml.
+
+Editors using yaml-language-server (e.g. the VS Code YAML extension) can use it
+for validation and autocompletion. --install writes keynginx.schema.json next to
+keynginx.yaml and adds the schema modeline to the top of keynginx.yaml.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigSchema,
+	}
+	schemaCmd.Flags().StringVarP(&configSchemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+	schemaCmd.Flags().BoolVar(&configSchemaInstall, "install", false, "Write keynginx.schema.json and link it from keynginx.yaml")
+	configCmd.AddCommand(schemaCmd)
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Validate keynginx.yaml against the schema",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigValidate,
+	})
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	cfg, err := loadProjectConfig(configProject)
+	if err != nil {
+		return fmt.Errorf("failed to load project configuration: %w", err)
+	}
+
+	root := &yaml.Node{}
+	if err := root.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	key := ""
+	if len(args) == 1 {
+		key = args[0]
+	}
+
+	node, err := config.LookupNode(root, key)
+	if err != nil {
+		return err
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		fmt.Println(node.Value)
+		return nil
+	}
+
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	return editProjectConfig(func(editor *config.Editor) (string, error) {
+		if err := editor.Set(args[0], args[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Set %s = %s", args[0], args[1]), nil
+	})
+}
+
+func runConfigAddService(cmd *cobra.Command, args []string) error {
+	port, err := strconv.Atoi(args[1])
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port: %s", args[1])
+	}
+
+	path := "/"
+	if len(args) == 3 {
+		path = args[2]
+	}
+
+	// With --env the editor only sees the overlay, where a service named
+	// like a base one would be merged into it instead of added.
+	if projectEnv() != "" {
+		basePath, err := findProjectConfig(configProject)
+		if err != nil {
+			return err
+		}
+		base, err := config.LoadConfig(basePath)
+		if err != nil {
+			return err
+		}
+		for _, service := range base.Nginx.Services {
+			if service.Name == args[0] {
+				return fmt.Errorf("service %s already exists in %s", args[0], basePath)
+			}
+		}
+	}
+
+	return editProjectConfig(func(editor *config.Editor) (string, error) {
+		if err := editor.AddService(args[0], port, path); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Added service: %s -> %s:%d%s", args[0], args[0], port, path), nil
+	})
+}
+
+func runConfigRemoveService(cmd *cobra.Command, args []string) error {
+	return editProjectConfig(func(editor *config.Editor) (string, error) {
+		if err := editor.RemoveService(args[0]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Removed service: %s", args[0]), nil
+	})
+}
+
+func runConfigSetHeader(cmd *cobra.Command, args []string) error {
+	key, err := headerConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	return editProjectConfig(func(editor *config.Editor) (string, error) {
+		if err := editor.SetValue(key, args[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Set header %s: %s", args[0], args[1]), nil
+	})
+}
+
+func runConfigRemoveHeader(cmd *cobra.Command, args []string) error {
+	key, err := headerConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	return editProjectConfig(func(editor *config.Editor) (string, error) {
+		if err := editor.Remove(key); err != nil {
+			return "", fmt.Errorf("header %s not found", args[0])
+		}
+		return fmt.Sprintf("Removed header: %s", args[0]), nil
+	})
+}
+
+func runConfigSetSecurityLevel(cmd *cobra.Command, args []string) error {
+	return editProjectConfig(func(editor *config.Editor) (string, error) {
+		if err := editor.SetSecurityLevel(args[0]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Security level set to %s", args[0]), nil
+	})
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config.GenerateSchema()); err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	data := buf.Bytes()
+
+	if configSchemaInstall {
+		return installConfigSchema(data)
+	}
+
+	if configSchemaOutput == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(configSchemaOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	fmt.Printf("✅ Schema written to %s\n", configSchemaOutput)
+	return nil
+}
+
+func installConfigSchema(schema []byte) error {
+	basePath, err := findProjectConfig(configProject)
+	if err != nil {
+		return err
+	}
+
+	schemaPath := filepath.Join(filepath.Dir(basePath), "keynginx.schema.json")
+	if err := os.WriteFile(schemaPath, schema, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+
+	editor, err := config.OpenEditor(basePath)
+	if err != nil {
+		return err
+	}
+	editor.SetSchemaModeline("./keynginx.schema.json")
+	if err := editor.Save(); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("✅ Schema written to %s\n", schemaPath)
+	fmt.Printf("✅ Linked from %s\n", basePath)
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	basePath, err := findProjectConfig(configProject)
+	if err != nil {
+		return err
+	}
+
+	files := []string{basePath}
+	if env := projectEnv(); env != "" {
+		files = append(files, config.OverlayPath(basePath, env))
+	}
+
+	schema := config.GenerateSchema()
+	failed := false
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		schemaErrors, err := schema.ValidateYAML(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		if len(schemaErrors) == 0 {
+			fmt.Printf("✅ %s matches the schema\n", file)
+			continue
+		}
+
+		failed = true
+		fmt.Printf("❌ %s:\n", file)
+		for _, schemaError := range schemaErrors {
+			fmt.Printf("   • %s\n", schemaError.Error())
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("configuration does not match the schema")
+	}
+
+	cfg, err := loadProjectConfig(configProject)
+	if err != nil {
+		return fmt.Errorf("failed to load project configuration: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	fmt.Println("✅ Configuration is valid")
+	return nil
+}
+
+func headerConfigKey(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, ". :") {
+		return "", fmt.Errorf("invalid header name: %q", name)
+	}
+	if configSecurityHeader {
+		return "security.custom_headers." + name, nil
+	}
+	return "nginx.custom_headers." + name, nil
+}
+
+// editProjectConfig applies an edit to the base config (or the --env overlay),
+// validates the resulting effective configuration and only then writes it.
+func editProjectConfig(edit func(editor *config.Editor) (string, error)) error {
+	basePath, err := findProjectConfig(configProject)
+	if err != nil {
+		return err
+	}
+
+	path := basePath
+	env := projectEnv()
+	if env != "" {
+		path = config.OverlayPath(basePath, env)
+	}
+
+	editor, err := config.OpenEditor(path)
+	if err != nil {
+		return err
+	}
+
+	message, err := edit(editor)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := editor.Config()
+	if err != nil {
+		return err
+	}
+
+	if env != "" {
+		base, err := os.ReadFile(basePath)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		overlay, err := editor.Bytes()
+		if err != nil {
+			return err
+		}
+		if cfg, err = config.ParseConfigWithOverlay(base, overlay); err != nil {
+			return err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	if err := editor.Save(); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("✅ %s (%s)\n", message, editor.Path())
+	fmt.Println("💡 Run 'keynginx generate' to apply the change")
+	return nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sinhaparth5/keynginx/internal/utils"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Regenerate project files from keynginx.yaml",
+	Long: `Regenerate the Nginx configuration and Docker Compose file from keynginx.yaml.
+
+The base keynginx.yaml is merged with keynginx.<env>.yaml when --env is set,
+so the same project can produce dev, staging and prod variants.
+
+The generated configuration is tested with nginx -t in a throwaway container
+of docker.nginx_image when Docker is available; problems are reported with
+the keynginx.yaml setting that produced them.
+
+Examples:
+  keynginx generate
+  keynginx generate --env staging
+  keynginx generate --env prod --certs`,
+	RunE: runGenerate,
+}
+
+var (
+	generateProject      string
+	generateCerts        bool
+	generateSkipValidate bool
+)
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().StringVarP(&generateProject, "project", "p", ".", "Project directory path")
+	generateCmd.Flags().BoolVar(&generateCerts, "certs", false, "Regenerate SSL certificates even if they exist")
+	generateCmd.Flags().BoolVar(&generateSkipValidate, "skip-validate", false, "Skip testing the generated configuration with nginx -t")
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	fmt.Println("⚙️  KeyNginx Generate")
+	fmt.Println("=====================")
+
+	cfg, err := loadProjectConfig(generateProject)
+	if err != nil {
+		return fmt.Errorf("failed to load project configuration: %w", err)
+	}
+
+	if env := projectEnv(); env != "" {
+		fmt.Printf("🌍 Environment: %s\n", env)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	if err := utils.EnsureDirectory(cfg.Project.OutputDir); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	fmt.Println("🔐 Checking SSL certificates...")
+	if err := generateSSLCertificates(cfg, generateCerts); err != nil {
+		return fmt.Errorf("failed to generate SSL certificates: %w", err)
+	}
+
+	fmt.Println("⚙️  Generating Nginx configuration...")
+	if err := generateNginxConfiguration(cfg); err != nil {
+		return fmt.Errorf("failed to generate Nginx configuration: %w", err)
+	}
+
+	fmt.Println("🐳 Generating Docker Compose configuration...")
+	if err := generateDockerCompose(cfg); err != nil {
+		return fmt.Errorf("failed to generate Docker Compose: %w", err)
+	}
+
+	if !generateSkipValidate {
+		if err := checkNginxConfiguration(cfg); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✅ Project files regenerated in %s\n", cfg.Project.OutputDir)
+	return nil
+}
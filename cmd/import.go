@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/importer"
+	"github.com/sinhaparth5/keynginx/internal/utils"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create keynginx.yaml from an existing setup",
+	Long: `Create a KeyNginx project configuration from an existing setup.
+
+The imported keynginx.yaml is written to the output directory; review it and
+run 'keynginx generate' there to produce the project files.
+
+Examples:
+  keynginx import nginx /etc/nginx/nginx.conf
+  keynginx import nginx ./legacy/site.conf -o ./site --overwrite
+  keynginx import compose ./docker-compose.yml`,
+}
+
+var (
+	importOutputDir string
+	importOverwrite bool
+)
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.PersistentFlags().StringVarP(&importOutputDir, "output", "o", "./keynginx-output", "Output directory")
+	importCmd.PersistentFlags().BoolVar(&importOverwrite, "overwrite", false, "Overwrite an existing keynginx.yaml")
+
+	importCmd.AddCommand(&cobra.Command{
+		Use:   "nginx <file>",
+		Short: "Import an nginx configuration",
+		Long: `Import an nginx configuration, following its include directives.
+
+Server blocks become the main server and sites, locations become services,
+redirects and static services, and upstreams, rate limits, headers and
+certificate paths are carried over. Directives without a keynginx setting are
+listed with their file and line so they can be handled by hand, for example
+with nginx.snippets.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportNginx,
+	})
+
+	importCmd.AddCommand(&cobra.Command{
+		Use:   "compose <file>",
+		Short: "Import the HTTP services of a docker-compose.yml",
+		Long: `Import the services of a Docker Compose project.
+
+Every service with a published or exposed HTTP port becomes a proxied service:
+frontend-like names (web, frontend, ui, app) are routed at /, API-like names
+(api, backend, server) at /api and the rest at /<name>. The nginx container
+joins the network the services share, so they are reached by name.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportCompose,
+	})
+}
+
+func runImportNginx(cmd *cobra.Command, args []string) error {
+	fmt.Println("📥 KeyNginx Import")
+	fmt.Println("==================")
+
+	configFile, err := importConfigFile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📄 Reading %s...\n", args[0])
+	result, err := importer.ImportNginx(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to import nginx configuration: %w", err)
+	}
+
+	return saveImport(result, configFile)
+}
+
+func runImportCompose(cmd *cobra.Command, args []string) error {
+	fmt.Println("📥 KeyNginx Import")
+	fmt.Println("==================")
+
+	configFile, err := importConfigFile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📄 Reading %s...\n", args[0])
+	result, err := importer.ImportCompose(config.NewDefaultConfig(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to import compose services: %w", err)
+	}
+
+	return saveImport(result, configFile)
+}
+
+func importConfigFile() (string, error) {
+	configFile := filepath.Join(importOutputDir, "keynginx.yaml")
+	if utils.FileExists(configFile) && !importOverwrite {
+		return "", fmt.Errorf("%s already exists (use --overwrite)", configFile)
+	}
+	return configFile, nil
+}
+
+func saveImport(result *importer.Result, configFile string) error {
+	cfg := result.Config
+	cfg.Project.OutputDir = importOutputDir
+
+	if err := cfg.Save(configFile); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("💾 Saved %s\n", configFile)
+	fmt.Printf("🌐 Domain: %s\n", cfg.Project.Domain)
+	fmt.Printf("🔄 Services: %d\n", len(cfg.AllServices()))
+	if len(cfg.Nginx.Sites) > 0 {
+		fmt.Printf("🌍 Sites: %d\n", len(cfg.Nginx.Sites))
+	}
+	if len(cfg.Nginx.Upstreams) > 0 {
+		fmt.Printf("⚖️  Upstreams: %d\n", len(cfg.Nginx.Upstreams))
+	}
+	if len(cfg.Nginx.Streams) > 0 {
+		fmt.Printf("🔌 Streams: %d\n", len(cfg.Nginx.Streams))
+	}
+	if cfg.Docker.ExternalNetwork {
+		fmt.Printf("🔗 Network: %s\n", cfg.Docker.NetworkName)
+	}
+
+	printImportResult(result)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("\n❌ The imported configuration needs changes before generating: %v\n", err)
+	}
+
+	fmt.Println("\n🚀 Next Steps:")
+	fmt.Printf("   1. Review %s\n", configFile)
+	fmt.Printf("   2. keynginx generate -p %s\n", importOutputDir)
+
+	return nil
+}
+
+func printImportResult(result *importer.Result) {
+	if len(result.Issues) > 0 {
+		fmt.Printf("\n⚠️  %d item(s) could not be imported:\n", len(result.Issues))
+		for _, issue := range result.Issues {
+			fmt.Printf("   • %s\n", issue)
+		}
+	}
+
+	if len(result.Notes) > 0 {
+		fmt.Println("\n📝 Manual steps:")
+		for _, note := range result.Notes {
+			fmt.Printf("   • %s\n", note)
+		}
+	}
+}
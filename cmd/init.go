@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/sinhaparth5/keynginx/internal/config"
 	"github.com/sinhaparth5/keynginx/internal/crypto"
+	"github.com/sinhaparth5/keynginx/internal/docker"
+	"github.com/sinhaparth5/keynginx/internal/importer"
 	"github.com/sinhaparth5/keynginx/internal/nginx"
 	"github.com/sinhaparth5/keynginx/internal/utils"
 )
@@ -23,7 +26,10 @@ var initCmd = &cobra.Command{
 - Docker Compose configuration
 - Project configuration file
 
-This sets up everything needed for a secure web server.`,
+This sets up everything needed for a secure web server. With --from-compose
+the HTTP services of an existing docker-compose.yml are added and nginx joins
+their network. When Docker is available the generated configuration is tested
+with nginx -t before the project is reported ready.`,
 	RunE: runInit,
 }
 
@@ -37,6 +43,8 @@ var (
 	initOverwrite     bool
 	initServices      []string
 	initCustomHeaders []string
+	initFromCompose   string
+	initSkipValidate  bool
 )
 
 func init() {
@@ -51,6 +59,8 @@ func init() {
 	initCmd.Flags().BoolVar(&initOverwrite, "overwrite", false, "Overwrite existing files")
 	initCmd.Flags().StringSliceVar(&initServices, "services", []string{}, "Services in format 'name:port:path' (e.g. 'frontend:3000:/')")
 	initCmd.Flags().StringSliceVar(&initCustomHeaders, "custom-headers", []string{}, "Custom headers in format 'Key:Value'")
+	initCmd.Flags().StringVar(&initFromCompose, "from-compose", "", "Add the HTTP services of a docker-compose.yml")
+	initCmd.Flags().BoolVar(&initSkipValidate, "skip-validate", false, "Skip testing the generated configuration with nginx -t")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -67,6 +77,15 @@ func runInit(cmd *cobra.Command, args []string) error {
 		configureFromFlags(cfg)
 	}
 
+	if initFromCompose != "" {
+		fmt.Printf("🐳 Importing services from %s...\n", initFromCompose)
+		result, err := importer.ImportCompose(cfg, initFromCompose)
+		if err != nil {
+			return fmt.Errorf("failed to import compose services: %w", err)
+		}
+		printImportResult(result)
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("configuration validation failed: %w", err)
 	}
@@ -81,7 +100,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("🔐 Generating SSL certificates for %s...\n", cfg.Project.Domain)
-	if err := generateSSLCertificates(cfg); err != nil {
+	if err := generateSSLCertificates(cfg, true); err != nil {
 		return fmt.Errorf("failed to generate SSL certificates: %w", err)
 	}
 
@@ -101,6 +120,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
+	if !initSkipValidate {
+		if err := checkNginxConfiguration(cfg); err != nil {
+			return err
+		}
+	}
+
 	printInitSuccess(cfg)
 
 	return nil
@@ -190,11 +215,60 @@ func configureFromFlags(cfg *config.Config) {
 	}
 }
 
-func generateSSLCertificates(cfg *config.Config) error {
+func generateSSLCertificates(cfg *config.Config, overwrite bool) error {
 	generator := crypto.NewGenerator()
 
+	sslDir := filepath.Join(cfg.Project.OutputDir, "ssl")
+	if err := utils.EnsureDirectory(sslDir); err != nil {
+		return err
+	}
+
+	privateKeyPath := filepath.Join(sslDir, "private.key")
+	certificatePath := filepath.Join(sslDir, "certificate.crt")
+
+	hosts := cfg.ServiceHosts()
+	if overwrite || !certificateCoversHosts(generator, certificatePath, hosts) {
+		if err := generateCertificate(generator, cfg, cfg.Project.Domain, hosts, privateKeyPath, certificatePath); err != nil {
+			return err
+		}
+	}
+
+	for _, site := range cfg.Nginx.Sites {
+		if !site.GeneratesCertificate() {
+			continue
+		}
+
+		certificateFile, keyFile := site.CertificateFiles()
+		siteCertificatePath := filepath.Join(sslDir, certificateFile)
+		if !overwrite && utils.FileExists(siteCertificatePath) {
+			continue
+		}
+
+		if err := generateCertificate(generator, cfg, site.ServerNames[0], site.ServerNames[1:], filepath.Join(sslDir, keyFile), siteCertificatePath); err != nil {
+			return fmt.Errorf("site %s: %w", site.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func certificateCoversHosts(generator *crypto.Generator, certificatePath string, hosts []string) bool {
+	info, err := generator.ValidateCertificate(certificatePath)
+	if err != nil {
+		return false
+	}
+
+	for _, host := range hosts {
+		if !info.CoversHost(host) {
+			return false
+		}
+	}
+	return true
+}
+
+func generateCertificate(generator *crypto.Generator, cfg *config.Config, domain string, altNames []string, privateKeyPath, certificatePath string) error {
 	certReq := crypto.CertificateRequest{
-		Domain:       cfg.Project.Domain,
+		Domain:       domain,
 		KeySize:      cfg.SSL.KeySize,
 		ValidityDays: cfg.SSL.ValidityDays,
 		Country:      cfg.SSL.Country,
@@ -203,6 +277,7 @@ func generateSSLCertificates(cfg *config.Config) error {
 		Organization: cfg.SSL.Organization,
 		Unit:         cfg.SSL.Unit,
 		Email:        cfg.SSL.Email,
+		DNSNames:     altNames,
 	}
 
 	keyPair, err := generator.GenerateKeyPair(certReq)
@@ -210,14 +285,6 @@ func generateSSLCertificates(cfg *config.Config) error {
 		return err
 	}
 
-	sslDir := filepath.Join(cfg.Project.OutputDir, "ssl")
-	if err := utils.EnsureDirectory(sslDir); err != nil {
-		return err
-	}
-
-	privateKeyPath := filepath.Join(sslDir, "private.key")
-	certificatePath := filepath.Join(sslDir, "certificate.crt")
-
 	return generator.SaveKeyPair(keyPair, privateKeyPath, certificatePath)
 }
 
@@ -233,6 +300,74 @@ func generateNginxConfiguration(cfg *config.Config) error {
 	return os.WriteFile(nginxConfigPath, []byte(nginxConfig), 0644)
 }
 
+// checkNginxConfiguration tests the generated files with nginx -t. Without
+// Docker the test is skipped with a warning; the files are still written.
+func checkNginxConfiguration(cfg *config.Config) error {
+	manager, err := docker.NewManager()
+	if err == nil {
+		defer manager.Close()
+		err = manager.CheckDockerAvailability()
+	}
+	if err != nil {
+		fmt.Println("⚠️  Docker is not available, skipping nginx -t")
+		return nil
+	}
+	return testNginxConfiguration(manager, cfg)
+}
+
+func testNginxConfiguration(manager *docker.Manager, cfg *config.Config) error {
+	fmt.Printf("🧪 Testing configuration with %s... ", cfg.Docker.NginxImage)
+	warnings, err := manager.ValidateConfig(cfg)
+
+	var invalid *docker.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		fmt.Println("❌")
+		printValidationError(invalid)
+		return fmt.Errorf("nginx rejected the generated configuration")
+	case err != nil:
+		fmt.Println("⚠️")
+		fmt.Printf("   Could not run nginx -t: %v\n", err)
+		return nil
+	}
+
+	fmt.Println("✅")
+	printDiagnostics(warnings)
+	return nil
+}
+
+// printValidationError lists the problems nginx -t reported, or its raw
+// output when none could be read from it.
+func printValidationError(invalid *docker.ValidationError) {
+	if len(invalid.Diagnostics) == 0 {
+		for _, line := range strings.Split(strings.TrimSpace(invalid.Output), "\n") {
+			fmt.Printf("   %s\n", line)
+		}
+		return
+	}
+	printDiagnostics(invalid.Diagnostics)
+}
+
+func printDiagnostics(diagnostics []nginx.Diagnostic) {
+	for _, diagnostic := range diagnostics {
+		icon := "⚠️ "
+		if diagnostic.Level == "emerg" || diagnostic.Level == "alert" || diagnostic.Level == "crit" {
+			icon = "❌"
+		}
+		location := ""
+		if diagnostic.Line > 0 {
+			location = fmt.Sprintf("nginx.conf:%d: ", diagnostic.Line)
+		}
+		fmt.Printf("   %s %s%s\n", icon, location, diagnostic.Message)
+		if diagnostic.Directive != "" {
+			fmt.Printf("      %s\n", diagnostic.Directive)
+		}
+		if diagnostic.Setting != "" {
+			fmt.Printf("      ↳ keynginx.yaml: %s\n", diagnostic.Setting)
+		}
+	}
+}
+
 func generateDockerCompose(cfg *config.Config) error {
 	generator := nginx.NewGenerator()
 
@@ -257,7 +392,22 @@ func printInitSuccess(cfg *config.Config) {
 	if len(cfg.Nginx.Services) > 0 {
 		fmt.Println("\n🔄 Services:")
 		for _, service := range cfg.Nginx.Services {
-			fmt.Printf("   • %s: %s -> %s:%d\n", service.Name, service.Path, service.Name, service.Port)
+			route := service.Path
+			if host := service.HostName(cfg.Project.Domain); host != "" {
+				route = host + service.LocationPath()
+			}
+			target := fmt.Sprintf("%s:%d", service.Name, service.Port)
+			if service.IsStatic() {
+				target = fmt.Sprintf("%s (%s)", service.Static.Root, service.Type)
+			}
+			fmt.Printf("   • %s: %s -> %s\n", service.Name, route, target)
+		}
+	}
+
+	if len(cfg.Nginx.Sites) > 0 {
+		fmt.Println("\n🌍 Sites:")
+		for _, site := range cfg.Nginx.Sites {
+			fmt.Printf("   • %s: %s\n", site.Name, strings.Join(site.ServerNames, ", "))
 		}
 	}
 
@@ -281,5 +431,5 @@ func printInitSuccess(cfg *config.Config) {
 	fmt.Println("\n🔧 Customize your setup:")
 	fmt.Println("   • Edit nginx.conf for advanced configuration")
 	fmt.Println("   • Modify docker-compose.yml to add your services")
-	fmt.Println("   • Update keynginx.yaml and regenerate with 'keynginx init'")
+	fmt.Println("   • Update keynginx.yaml and regenerate with 'keynginx generate'")
 }
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/docker"
+	"github.com/sinhaparth5/keynginx/internal/utils"
+)
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Apply configuration and certificate changes without a restart",
+	Long: `Regenerate nginx.conf from keynginx.yaml and reload the running container.
+Certificates for new sites and service hosts are generated first.
+
+Unlike a restart, a reload keeps open connections and WebSocket sessions:
+nginx starts workers with the new configuration and certificates and lets the
+old ones finish. The configuration is tested with nginx -t inside the
+container first, and if the test or the reload fails the previous nginx.conf
+is restored, so the server keeps running on the last good configuration.
+
+Examples:
+  keynginx reload
+  keynginx reload -p ./keynginx-output --env staging`,
+	RunE: runReload,
+}
+
+var reloadProject string
+
+func init() {
+	rootCmd.AddCommand(reloadCmd)
+
+	reloadCmd.Flags().StringVarP(&reloadProject, "project", "p", ".", "Project directory path")
+}
+
+func runReload(cmd *cobra.Command, args []string) error {
+	fmt.Println("🔄 KeyNginx Reload")
+	fmt.Println("==================")
+
+	cfg, err := loadProjectConfig(reloadProject)
+	if err != nil {
+		return fmt.Errorf("failed to load project configuration: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	manager, err := docker.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker manager: %w", err)
+	}
+	defer manager.Close()
+
+	fmt.Print("🐳 Checking Docker availability... ")
+	if err := manager.CheckDockerAvailability(); err != nil {
+		fmt.Println("❌")
+		return err
+	}
+	fmt.Println("✅")
+
+	return reloadProjectConfig(manager, cfg)
+}
+
+// reloadProjectConfig generates missing certificates, regenerates nginx.conf
+// and hot-reloads the container, restoring the previous file when nginx
+// rejects the new one.
+func reloadProjectConfig(manager *docker.Manager, cfg *config.Config) error {
+	configPath := filepath.Join(cfg.Project.OutputDir, "nginx.conf")
+	var previous []byte
+	if utils.FileExists(configPath) {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", configPath, err)
+		}
+		previous = data
+	}
+
+	// New sites and service hosts need certificates before nginx -t accepts
+	// the configuration.
+	fmt.Println("🔐 Checking SSL certificates...")
+	if err := generateSSLCertificates(cfg, false); err != nil {
+		return fmt.Errorf("failed to generate SSL certificates: %w", err)
+	}
+
+	fmt.Println("⚙️  Generating Nginx configuration...")
+	if err := generateNginxConfiguration(cfg); err != nil {
+		return fmt.Errorf("failed to generate Nginx configuration: %w", err)
+	}
+
+	fmt.Print("🔄 Reloading nginx... ")
+	err := manager.Reload(cfg, previous)
+	if err == nil {
+		fmt.Println("✅")
+		warnContainerDrift(manager, cfg)
+		return nil
+	}
+
+	fmt.Println("❌")
+	var invalid *docker.ValidationError
+	if errors.As(err, &invalid) {
+		printValidationError(invalid)
+	}
+	var rollback *docker.RollbackError
+	if errors.As(err, &rollback) {
+		fmt.Println("↩️  Restored the previous nginx.conf; the server keeps running on it")
+	}
+	return err
+}
+
+// warnContainerDrift reports mount and port changes, which a reload cannot
+// apply to the existing container.
+func warnContainerDrift(manager *docker.Manager, cfg *config.Config) {
+	changes, err := manager.ContainerDrift(cfg)
+	if err != nil {
+		fmt.Printf("⚠️  Could not compare mounts and ports with the container: %v\n", err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Println("⚠️  Not applied by a reload:")
+	for _, change := range changes {
+		fmt.Printf("   • %s\n", change)
+	}
+	fmt.Println("💡 Run 'keynginx up --recreate' to apply them")
+}
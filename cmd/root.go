@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -14,12 +15,22 @@ var rootCmd = &cobra.Command{
 	Version: getVersion(),
 }
 
+var rootEnv string
+
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVarP(&rootEnv, "env", "e", "", "Environment overlay to apply (keynginx.<env>.yaml, defaults to $KEYNGINX_ENV)")
+}
+
+func projectEnv() string {
+	if rootEnv != "" {
+		return rootEnv
+	}
+	return os.Getenv("KEYNGINX_ENV")
 }
 
 func getVersion() string {
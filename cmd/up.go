@@ -18,7 +18,7 @@ var upCmd = &cobra.Command{
 
 This command will:
 • Check if Docker is running
-• Validate project configuration
+• Validate project configuration and test it with nginx -t
 • Create and start the Nginx container
 • Display connection information
 
@@ -27,9 +27,10 @@ Use this after running 'keynginx init' to start your server.`,
 }
 
 var (
-	upDetach   bool
-	upRecreate bool
-	upProject  string
+	upDetach       bool
+	upRecreate     bool
+	upProject      string
+	upSkipValidate bool
 )
 
 func init() {
@@ -38,6 +39,7 @@ func init() {
 	upCmd.Flags().BoolVarP(&upDetach, "detach", "d", true, "Run containers in background")
 	upCmd.Flags().BoolVar(&upRecreate, "recreate", false, "Recreate containers even if they exist")
 	upCmd.Flags().StringVarP(&upProject, "project", "p", ".", "Project directory path")
+	upCmd.Flags().BoolVar(&upSkipValidate, "skip-validate", false, "Skip testing the configuration with nginx -t")
 }
 
 func runUp(cmd *cobra.Command, args []string) error {
@@ -66,13 +68,22 @@ func runUp(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get project status: %w", err)
 	}
 
-	if status.Status != "not-found" {
-		if status.IsRunning() && !upRecreate {
-			fmt.Printf("⚠️  Container is already running!\n\n")
-			printServerInfo(status)
-			return nil
+	if status.IsRunning() && !upRecreate {
+		fmt.Printf("⚠️  Container is already running!\n\n")
+		printServerInfo(status)
+		fmt.Println("\n💡 Apply configuration changes without a restart: keynginx reload")
+		return nil
+	}
+
+	// Test before the running container is removed, so a bad configuration
+	// does not take the server down.
+	if !upSkipValidate {
+		if err := testNginxConfiguration(manager, cfg); err != nil {
+			return err
 		}
+	}
 
+	if status.Status != "not-found" {
 		if upRecreate {
 			fmt.Print("🔄 Recreating container... ")
 			if err := manager.StopAndRemoveContainer(cfg); err != nil {
@@ -180,6 +191,15 @@ func printServerInfo(status *docker.ProjectStatus) {
 }
 
 func loadProjectConfig(projectPath string) (*config.Config, error) {
+	path, err := findProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.LoadConfigWithEnv(path, projectEnv())
+}
+
+func findProjectConfig(projectPath string) (string, error) {
 	configPaths := []string{
 		fmt.Sprintf("%s/keynginx.yaml", projectPath),
 		fmt.Sprintf("%s/keynginx.yml", projectPath),
@@ -189,9 +209,9 @@ func loadProjectConfig(projectPath string) (*config.Config, error) {
 
 	for _, path := range configPaths {
 		if utils.FileExists(path) {
-			return config.LoadConfig(path)
+			return path, nil
 		}
 	}
 
-	return nil, fmt.Errorf("KeyNginx configuration file not found in %s", projectPath)
+	return "", fmt.Errorf("KeyNginx configuration file not found in %s", projectPath)
 }
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/docker"
+	"github.com/sinhaparth5/keynginx/internal/watch"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Regenerate and reload on every configuration change",
+	Long: `Watch keynginx.yaml, the active overlay, template overrides and certificates,
+and apply every change to the running container.
+
+Changes are debounced, then nginx.conf and docker-compose.yml are regenerated,
+tested with nginx -t and hot-reloaded as with 'keynginx reload'. A change that
+nginx rejects is rolled back in nginx.conf and the server keeps running on the
+last good configuration until the next save. When the container is not
+running the files are only regenerated and tested.
+
+Examples:
+  keynginx watch
+  keynginx watch -p ./keynginx-output --env dev`,
+	RunE: runWatch,
+}
+
+var (
+	watchProject  string
+	watchDebounce time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVarP(&watchProject, "project", "p", ".", "Project directory path")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 300*time.Millisecond, "Quiet period before applying a batch of changes")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	fmt.Println("👀 KeyNginx Watch")
+	fmt.Println("=================")
+
+	configFile, err := findProjectConfig(watchProject)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadProjectConfig(watchProject)
+	if err != nil {
+		return fmt.Errorf("failed to load project configuration: %w", err)
+	}
+
+	manager, err := docker.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker manager: %w", err)
+	}
+	defer manager.Close()
+
+	fmt.Print("🐳 Checking Docker availability... ")
+	if err := manager.CheckDockerAvailability(); err != nil {
+		fmt.Println("❌")
+		return err
+	}
+	fmt.Println("✅")
+
+	paths := watchedPaths(configFile, cfg)
+	watcher, err := watch.New(paths, watchDebounce)
+	if err != nil {
+		return fmt.Errorf("failed to watch project files: %w", err)
+	}
+	defer func() { watcher.Close() }()
+
+	fmt.Println("📂 Watching:")
+	printWatchedPaths(paths)
+	fmt.Println("\n💡 Press Ctrl+C to stop")
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	for {
+		select {
+		case <-interrupt:
+			fmt.Println("\n👋 Stopped watching")
+			return nil
+		case err := <-watcher.Errors():
+			// A failed watcher stops delivering changes, so start a new one.
+			fmt.Printf("\n⚠️  Watching failed: %v; restarting\n", err)
+			watcher.Close()
+			if watcher, err = watch.New(paths, watchDebounce); err != nil {
+				return fmt.Errorf("failed to watch project files: %w", err)
+			}
+		case changed := <-watcher.Changes():
+			cfg = applyWatchedChanges(manager, configFile, cfg, changed)
+
+			// New sites, certificate directories or output_dir change what
+			// needs watching.
+			current := watchedPaths(configFile, cfg)
+			if reflect.DeepEqual(current, paths) {
+				continue
+			}
+			next, err := watch.New(current, watchDebounce)
+			if err != nil {
+				fmt.Printf("   ⚠️  Failed to watch the new paths, keeping the old ones: %v\n", err)
+				continue
+			}
+			watcher.Close()
+			watcher, paths = next, current
+			fmt.Println("   📂 Now watching:")
+			printWatchedPaths(paths)
+		}
+	}
+}
+
+func printWatchedPaths(paths []string) {
+	for _, path := range paths {
+		fmt.Printf("   • %s\n", path)
+	}
+}
+
+// watchedPaths lists the files that generated output depends on. The
+// templates and ssl directories are watched as a whole, so new overrides and
+// site certificates are picked up too.
+func watchedPaths(configFile string, cfg *config.Config) []string {
+	paths := []string{configFile}
+	if env := projectEnv(); env != "" {
+		paths = append(paths, config.OverlayPath(configFile, env))
+	}
+
+	paths = append(paths,
+		filepath.Join(cfg.Project.OutputDir, config.TemplatesDir),
+		filepath.Join(cfg.Project.OutputDir, "ssl"),
+	)
+	for _, site := range cfg.Nginx.Sites {
+		certificate, key := site.CertificateFiles()
+		for _, file := range []string{certificate, key} {
+			if dir := filepath.Dir(file); dir != "." {
+				paths = append(paths, filepath.Join(cfg.Project.OutputDir, "ssl", dir))
+			}
+		}
+	}
+	return uniqueStrings(paths)
+}
+
+// applyWatchedChanges generates missing certificates, regenerates and
+// reloads after a batch of changes and returns the configuration now in
+// effect. Failures are reported and the
+// previous configuration is kept, so watching continues.
+func applyWatchedChanges(manager *docker.Manager, configFile string, previous *config.Config, changed []string) *config.Config {
+	var names []string
+	for _, path := range changed {
+		if rel, err := filepath.Rel(filepath.Dir(configFile), path); err == nil {
+			path = rel
+		}
+		names = append(names, path)
+	}
+	fmt.Printf("\n[%s] 📝 Changed: %s\n", time.Now().Format("15:04:05"), strings.Join(names, ", "))
+
+	cfg, err := loadProjectConfig(watchProject)
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return previous
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("   ❌ Configuration validation failed: %v\n", err)
+		return previous
+	}
+
+	for _, change := range changedSettings(previous, cfg) {
+		fmt.Printf("   • %s\n", change)
+	}
+
+	if err := generateDockerCompose(cfg); err != nil {
+		fmt.Printf("   ❌ Failed to generate Docker Compose: %v\n", err)
+		return previous
+	}
+
+	status, err := manager.GetProjectStatus(cfg)
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return previous
+	}
+
+	if !status.IsRunning() {
+		if err := generateSSLCertificates(cfg, false); err != nil {
+			fmt.Printf("   ❌ Failed to generate SSL certificates: %v\n", err)
+			return previous
+		}
+		if err := generateNginxConfiguration(cfg); err != nil {
+			fmt.Printf("   ❌ Failed to generate Nginx configuration: %v\n", err)
+			return previous
+		}
+		fmt.Print("   ")
+		if err := testNginxConfiguration(manager, cfg); err != nil {
+			return previous
+		}
+		fmt.Println("   ⏸️  Container is not running; files regenerated")
+		return cfg
+	}
+
+	if err := reloadProjectConfig(manager, cfg); err != nil {
+		var rollback *docker.RollbackError
+		if !errors.As(err, &rollback) {
+			fmt.Printf("   ❌ %v\n", err)
+		}
+		return previous
+	}
+	return cfg
+}
+
+// changedSettings describes the differences between two configurations as
+// keynginx.yaml paths. Named lists such as services are compared by name.
+func changedSettings(previous, current *config.Config) []string {
+	var changes []string
+	compareValues("", reflect.ValueOf(*previous), reflect.ValueOf(*current), 0, &changes)
+	return changes
+}
+
+func compareValues(path string, previous, current reflect.Value, depth int, changes *[]string) {
+	if sameYAML(previous.Interface(), current.Interface()) {
+		return
+	}
+
+	switch {
+	case previous.Kind() == reflect.Struct && depth < 2:
+		for i := 0; i < previous.NumField(); i++ {
+			name := yamlName(previous.Type().Field(i))
+			if name == "" {
+				continue
+			}
+			compareValues(joinPath(path, name), previous.Field(i), current.Field(i), depth+1, changes)
+		}
+		return
+	case previous.Kind() == reflect.Slice && isNamedList(previous.Type()):
+		compareNamedLists(path, previous, current, changes)
+		return
+	}
+
+	*changes = append(*changes, path+" changed")
+}
+
+func compareNamedLists(path string, previous, current reflect.Value, changes *[]string) {
+	before := map[string]reflect.Value{}
+	var order []string
+	for i := 0; i < previous.Len(); i++ {
+		name := previous.Index(i).FieldByName("Name").String()
+		before[name] = previous.Index(i)
+		order = append(order, name)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < current.Len(); i++ {
+		item := current.Index(i)
+		name := item.FieldByName("Name").String()
+		seen[name] = true
+		old, ok := before[name]
+		switch {
+		case !ok:
+			*changes = append(*changes, fmt.Sprintf("%s[%s] added", path, name))
+		case !sameYAML(old.Interface(), item.Interface()):
+			*changes = append(*changes, fmt.Sprintf("%s[%s] changed", path, name))
+		}
+	}
+	for _, name := range order {
+		if !seen[name] {
+			*changes = append(*changes, fmt.Sprintf("%s[%s] removed", path, name))
+		}
+	}
+}
+
+func isNamedList(t reflect.Type) bool {
+	element := t.Elem()
+	if element.Kind() != reflect.Struct {
+		return false
+	}
+	field, ok := element.FieldByName("Name")
+	return ok && field.Type.Kind() == reflect.String
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sameYAML(a, b interface{}) bool {
+	left, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+type AccessConfig struct {
+	Allow []string `yaml:"allow,omitempty" desc:"IP addresses or CIDR ranges allowed in; everyone else is denied"`
+	Deny  []string `yaml:"deny,omitempty" desc:"IP addresses or CIDR ranges rejected with 403; checked before allow"`
+}
+
+func (a AccessConfig) IsEmpty() bool {
+	return len(a.Allow) == 0 && len(a.Deny) == 0
+}
+
+func (a AccessConfig) validate(field string) error {
+	for _, entry := range append(append([]string{}, a.Deny...), a.Allow...) {
+		if entry != "all" && !ValidAddress(entry) {
+			return fmt.Errorf("invalid %s entry %q (expected an IP address or CIDR range)", field, entry)
+		}
+	}
+	return nil
+}
+
+// ValidAddress accepts a single IP address or a CIDR range.
+func ValidAddress(entry string) bool {
+	if net.ParseIP(entry) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(entry)
+	return err == nil
+}
+
+func (c *Config) RealIPHeader() string {
+	if c.Security.RealIPHeader == "" {
+		return "X-Forwarded-For"
+	}
+	return c.Security.RealIPHeader
+}
+
+func (c *Config) validateAccess() error {
+	if err := c.Security.Access.validate("security.access"); err != nil {
+		return err
+	}
+
+	for _, proxy := range c.Security.TrustedProxies {
+		if !ValidAddress(proxy) {
+			return fmt.Errorf("invalid security.trusted_proxies entry %q (expected an IP address or CIDR range)", proxy)
+		}
+	}
+
+	if header := c.Security.RealIPHeader; header != "" && !headerNamePattern.MatchString(header) {
+		return fmt.Errorf("invalid security.real_ip_header %q", header)
+	}
+
+	for _, service := range c.AllServices() {
+		if err := service.Access.validate("access"); err != nil {
+			return fmt.Errorf("service %s: %w", service.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	HtpasswdFile = "auth/htpasswd"
+	AuthDir      = "/etc/nginx/auth"
+	HtpasswdPath = AuthDir + "/htpasswd"
+)
+
+type BasicAuthConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty" desc:"Require a password for every location"`
+	Realm   string `yaml:"realm,omitempty" desc:"Realm shown in the browser prompt; defaults to Restricted"`
+}
+
+type ServiceAuthConfig struct {
+	Enabled *bool  `yaml:"enabled,omitempty" desc:"Require (true) or skip (false) the password for this service; defaults to security.basic_auth.enabled"`
+	Realm   string `yaml:"realm,omitempty" desc:"Realm for this service; defaults to security.basic_auth.realm"`
+}
+
+func (b BasicAuthConfig) RealmOrDefault() string {
+	if b.Realm == "" {
+		return "Restricted"
+	}
+	return b.Realm
+}
+
+// BasicAuthUsed reports whether any location requires a password, in which
+// case the htpasswd file has to exist and be mounted.
+func (c *Config) BasicAuthUsed() bool {
+	if c.Security.BasicAuth.Enabled {
+		return true
+	}
+	for _, service := range c.AllServices() {
+		if service.BasicAuth.Enabled != nil && *service.BasicAuth.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) validateBasicAuth() error {
+	realms := []string{c.Security.BasicAuth.Realm}
+	for _, service := range c.AllServices() {
+		realms = append(realms, service.BasicAuth.Realm)
+	}
+	for _, realm := range realms {
+		if strings.ContainsAny(realm, "\"\n") {
+			return fmt.Errorf("invalid basic auth realm %q", realm)
+		}
+	}
+	return nil
+}
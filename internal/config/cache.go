@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	CacheZone = "keynginx_cache"
+	CacheDir  = "/var/cache/nginx/keynginx"
+)
+
+var cacheStatusPattern = regexp.MustCompile(`^(any|[1-5][0-9]{2}( [1-5][0-9]{2})*)$`)
+
+var validUseStale = map[string]bool{
+	"error": true, "timeout": true, "invalid_header": true, "updating": true,
+	"http_500": true, "http_502": true, "http_503": true, "http_504": true,
+	"http_403": true, "http_404": true, "http_429": true, "off": true,
+}
+
+// CacheSettings are the caching rules shared by the project-level defaults and
+// the per-service overrides.
+type CacheSettings struct {
+	Key      string            `yaml:"key,omitempty" desc:"proxy_cache_key; defaults to $scheme$request_method$host$request_uri"`
+	Valid    map[string]string `yaml:"valid,omitempty" desc:"TTL per space-separated status code list or 'any', e.g. '200 302': 10m"`
+	Bypass   []string          `yaml:"bypass,omitempty" desc:"Variables that skip the cache when non-empty, e.g. $cookie_session or $http_authorization"`
+	UseStale []string          `yaml:"use_stale,omitempty" desc:"Serve stale responses on these upstream conditions (proxy_cache_use_stale)" enum:"error,timeout,invalid_header,updating,http_500,http_502,http_503,http_504,http_403,http_404,http_429,off"`
+	Lock     bool              `yaml:"lock,omitempty" desc:"Let only one request populate a missing cache entry (proxy_cache_lock)"`
+	MinUses  int               `yaml:"min_uses,omitempty" desc:"Requests needed before a response is cached" min:"1"`
+}
+
+type CacheConfig struct {
+	Enabled       bool   `yaml:"enabled,omitempty" desc:"Cache every proxied service unless it opts out"`
+	Size          string `yaml:"size,omitempty" desc:"Shared memory for cache keys; defaults to 10m" pattern:"^[0-9]+[kKmMgG]?$"`
+	MaxSize       string `yaml:"max_size,omitempty" desc:"Maximum size of cached data on disk, e.g. 1g" pattern:"^[0-9]+[kKmMgG]?$"`
+	Inactive      string `yaml:"inactive,omitempty" desc:"Remove entries not accessed for this long; defaults to 60m" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+	CacheSettings `yaml:",inline"`
+}
+
+type ServiceCacheConfig struct {
+	Enabled       *bool `yaml:"enabled,omitempty" desc:"Enable or disable caching for this service; defaults to nginx.cache.enabled"`
+	CacheSettings `yaml:",inline"`
+}
+
+// ServiceCache returns the effective cache rules for a service and whether
+// caching applies to it at all. Service settings override the project
+// defaults field by field.
+func (c *Config) ServiceCache(service ServiceConfig) (CacheSettings, bool) {
+	project := c.Nginx.Cache
+	enabled := project.Enabled
+	if service.Cache.Enabled != nil {
+		enabled = *service.Cache.Enabled
+	}
+	if !enabled || service.IsStatic() || service.IsGRPC() || service.SSE || service.WebSocket {
+		return CacheSettings{}, false
+	}
+
+	settings := project.CacheSettings
+	override := service.Cache.CacheSettings
+	if override.Key != "" {
+		settings.Key = override.Key
+	}
+	if len(override.Valid) > 0 {
+		settings.Valid = override.Valid
+	}
+	if len(override.Bypass) > 0 {
+		settings.Bypass = override.Bypass
+	}
+	if len(override.UseStale) > 0 {
+		settings.UseStale = override.UseStale
+	}
+	if override.Lock {
+		settings.Lock = true
+	}
+	if override.MinUses > 0 {
+		settings.MinUses = override.MinUses
+	}
+
+	if settings.Key == "" {
+		settings.Key = "$scheme$request_method$host$request_uri"
+	}
+	if len(settings.Valid) == 0 {
+		settings.Valid = map[string]string{"200 301 302": "10m", "404": "1m"}
+	}
+
+	return settings, true
+}
+
+func (c *Config) CacheEnabled() bool {
+	for _, service := range c.AllServices() {
+		if _, ok := c.ServiceCache(service); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheVolume is the named Docker volume that holds the proxy cache.
+func (c *Config) CacheVolume() string {
+	return fmt.Sprintf("keynginx-%s-cache", c.Project.Domain)
+}
+
+func (c CacheConfig) validate() error {
+	for name, value := range map[string]string{"size": c.Size, "max_size": c.MaxSize} {
+		if value != "" && !sizePattern.MatchString(value) {
+			return fmt.Errorf("invalid nginx.cache.%s %q", name, value)
+		}
+	}
+	if c.Inactive != "" && !durationPattern.MatchString(c.Inactive) {
+		return fmt.Errorf("invalid nginx.cache.inactive %q", c.Inactive)
+	}
+	if err := c.CacheSettings.validate(); err != nil {
+		return fmt.Errorf("nginx.cache: %w", err)
+	}
+	return nil
+}
+
+func (s CacheSettings) validate() error {
+	for statuses, ttl := range s.Valid {
+		if !cacheStatusPattern.MatchString(statuses) {
+			return fmt.Errorf("invalid valid status list %q", statuses)
+		}
+		if !durationPattern.MatchString(ttl) {
+			return fmt.Errorf("invalid TTL %q for %s", ttl, statuses)
+		}
+	}
+
+	for _, variable := range s.Bypass {
+		if !strings.HasPrefix(variable, "$") || strings.ContainsAny(variable, " ;") {
+			return fmt.Errorf("invalid bypass variable %q (e.g. $cookie_session)", variable)
+		}
+	}
+
+	for _, condition := range s.UseStale {
+		if !validUseStale[condition] {
+			return fmt.Errorf("invalid use_stale condition %q", condition)
+		}
+	}
+
+	if s.MinUses < 0 {
+		return fmt.Errorf("min_uses must not be negative")
+	}
+
+	return nil
+}
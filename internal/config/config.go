@@ -4,71 +4,205 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+var durationPattern = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d)?$`)
+
+var sizePattern = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+var validNextUpstream = map[string]bool{
+	"error":          true,
+	"timeout":        true,
+	"invalid_header": true,
+	"http_500":       true,
+	"http_502":       true,
+	"http_503":       true,
+	"http_504":       true,
+	"http_403":       true,
+	"http_404":       true,
+	"http_429":       true,
+	"non_idempotent": true,
+	"off":            true,
+}
+
+var extensionPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+var hostnamePattern = regexp.MustCompile(`^(\*\.)?[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
 type Config struct {
-	Project  ProjectConfig  `yaml:"project"`
-	SSL      SSLConfig      `yaml:"ssl"`
-	Nginx    NginxConfig    `yaml:"nginx"`
-	Security SecurityConfig `yaml:"security"`
-	Docker   DockerConfig   `yaml:"docker"`
+	Project  ProjectConfig  `yaml:"project" desc:"Project identity and output location"`
+	SSL      SSLConfig      `yaml:"ssl" desc:"Self-signed certificate settings"`
+	Nginx    NginxConfig    `yaml:"nginx" desc:"Nginx listener and routing settings"`
+	Security SecurityConfig `yaml:"security" desc:"Security headers and rate limiting"`
+	Logging  LoggingConfig  `yaml:"logging,omitempty" desc:"Access and error log settings"`
+	Docker   DockerConfig   `yaml:"docker" desc:"Docker container settings"`
 }
 
 type ProjectConfig struct {
-	Name      string `yaml:"name"`
-	Domain    string `yaml:"domain"`
-	OutputDir string `yaml:"output_dir"`
+	Name      string `yaml:"name" desc:"Project name"`
+	Domain    string `yaml:"domain" desc:"Primary domain; used for the certificate and container name"`
+	OutputDir string `yaml:"output_dir" desc:"Directory that receives the generated files"`
 }
 
 type SSLConfig struct {
-	KeySize      int    `yaml:"key_size"`
-	ValidityDays int    `yaml:"validity_days"`
-	Country      string `yaml:"country"`
-	State        string `yaml:"state"`
-	City         string `yaml:"city"`
-	Organization string `yaml:"organization"`
-	Unit         string `yaml:"unit"`
-	Email        string `yaml:"email"`
+	KeySize      int    `yaml:"key_size" desc:"RSA key size in bits" enum:"2048,3072,4096"`
+	ValidityDays int    `yaml:"validity_days" desc:"Certificate validity period in days" min:"1" max:"3650"`
+	Country      string `yaml:"country" desc:"Two-letter country code" pattern:"^[A-Za-z]{2}$"`
+	State        string `yaml:"state" desc:"State or province"`
+	City         string `yaml:"city" desc:"City or locality"`
+	Organization string `yaml:"organization" desc:"Organization name"`
+	Unit         string `yaml:"unit" desc:"Organizational unit"`
+	Email        string `yaml:"email" desc:"Contact email embedded in the certificate"`
 }
 
 type NginxConfig struct {
-	HTTPSPort     int               `yaml:"https_port"`
-	HTTPPort      int               `yaml:"http_port"`
-	ServerName    string            `yaml:"server_name"`
-	Services      []ServiceConfig   `yaml:"services"`
-	CustomHeaders map[string]string `yaml:"custom_headers"`
+	HTTPSPort     int               `yaml:"https_port" desc:"Published HTTPS port" min:"1" max:"65535"`
+	HTTPPort      int               `yaml:"http_port" desc:"Published HTTP port (redirects to HTTPS)" min:"1" max:"65535"`
+	ServerName    string            `yaml:"server_name" desc:"Nginx server_name for the main server block"`
+	Services      []ServiceConfig   `yaml:"services" desc:"Upstream services proxied by path"`
+	CustomHeaders map[string]string `yaml:"custom_headers" desc:"Extra response headers added to every response"`
+	Sites         []SiteConfig      `yaml:"sites,omitempty" desc:"Additional virtual hosts served by the same container"`
+	Upstreams     []UpstreamConfig  `yaml:"upstreams,omitempty" desc:"Load-balanced backend groups referenced by services"`
+	Cache         CacheConfig       `yaml:"cache,omitempty" desc:"Proxy response cache and its default rules"`
+	Redirects     []RedirectRule    `yaml:"redirects,omitempty" desc:"Redirects served by the main server block"`
+	Rewrites      []RewriteRule     `yaml:"rewrites,omitempty" desc:"Internal URI rewrites for the main server block"`
+	CanonicalHost string            `yaml:"canonical_host,omitempty" desc:"Redirect to the www or non-www form of the requested host" enum:"www,non-www"`
+	HTTP3         bool              `yaml:"http3,omitempty" desc:"Also serve HTTP/3 over QUIC on the HTTPS port (UDP); needs nginx 1.25+"`
+	Streams       []StreamConfig    `yaml:"streams,omitempty" desc:"Raw TCP/UDP services proxied in the nginx stream block"`
+	Snippets      SnippetsConfig    `yaml:"snippets,omitempty" desc:"Raw nginx directives injected at the template hook points"`
+}
+
+type UpstreamConfig struct {
+	Name       string           `yaml:"name" desc:"Upstream name referenced by services" pattern:"^[A-Za-z0-9_-]+$"`
+	Strategy   string           `yaml:"strategy,omitempty" desc:"Load balancing method; defaults to round_robin" enum:"round_robin,least_conn,ip_hash,hash"`
+	HashKey    string           `yaml:"hash_key,omitempty" desc:"Key for the hash strategy, e.g. $request_uri or $cookie_session"`
+	Consistent bool             `yaml:"consistent,omitempty" desc:"Use ketama consistent hashing with the hash strategy"`
+	Keepalive  int              `yaml:"keepalive,omitempty" desc:"Idle keepalive connections cached per worker" min:"0"`
+	Servers    []UpstreamServer `yaml:"servers" desc:"Backend servers"`
+}
+
+type UpstreamServer struct {
+	Address     string `yaml:"address" desc:"Backend address as host:port"`
+	Weight      int    `yaml:"weight,omitempty" desc:"Relative weight; defaults to 1" min:"1"`
+	MaxFails    int    `yaml:"max_fails,omitempty" desc:"Failed attempts before the server is marked unavailable" min:"0"`
+	FailTimeout string `yaml:"fail_timeout,omitempty" desc:"Window for max_fails and the unavailable period, e.g. 10s" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+	Backup      bool   `yaml:"backup,omitempty" desc:"Only used when the primary servers are unavailable"`
+}
+
+type SiteConfig struct {
+	Name           string            `yaml:"name" desc:"Site name; generated certificates are stored in ssl/<name>/" pattern:"^[A-Za-z0-9_-]+$"`
+	ServerNames    []string          `yaml:"server_names" desc:"Host names matched by this site; the first one is the certificate common name"`
+	Certificate    string            `yaml:"certificate,omitempty" desc:"Certificate file inside the ssl/ directory; generated when empty"`
+	CertificateKey string            `yaml:"certificate_key,omitempty" desc:"Private key file inside the ssl/ directory; generated when empty"`
+	SecurityLevel  string            `yaml:"security_level,omitempty" desc:"Security header profile for this site; defaults to security.level" enum:"strict,balanced,permissive"`
+	CustomHeaders  map[string]string `yaml:"custom_headers,omitempty" desc:"Extra response headers for this site"`
+	Services       []ServiceConfig   `yaml:"services,omitempty" desc:"Upstream services proxied by path on this site"`
+	Redirects      []RedirectRule    `yaml:"redirects,omitempty" desc:"Redirects served by this site"`
+	Rewrites       []RewriteRule     `yaml:"rewrites,omitempty" desc:"Internal URI rewrites for this site"`
+	CanonicalHost  string            `yaml:"canonical_host,omitempty" desc:"Redirect to the www or non-www form of the requested host" enum:"www,non-www"`
 }
 
 type ServiceConfig struct {
-	Name      string `yaml:"name"`
-	Port      int    `yaml:"port"`
-	Path      string `yaml:"path"`
-	ProxyPass string `yaml:"proxy_pass"`
+	Type        string             `yaml:"type,omitempty" desc:"proxy (default), static files, or spa (static with index.html fallback)" enum:"proxy,static,spa"`
+	Name        string             `yaml:"name" desc:"Service name; also the default upstream host"`
+	Port        int                `yaml:"port,omitempty" desc:"Service port" min:"1" max:"65535"`
+	Path        string             `yaml:"path" desc:"Location path routed to the service" pattern:"^$|^[/~@=^]"`
+	ProxyPass   string             `yaml:"proxy_pass,omitempty" desc:"Upstream URL, e.g. http://api:8000"`
+	Host        string             `yaml:"host,omitempty" desc:"Route by hostname in a dedicated server block; a bare label such as 'api' becomes api.<domain>"`
+	Upstream    string             `yaml:"upstream,omitempty" desc:"Name of an nginx.upstreams entry to balance across; replaces proxy_pass"`
+	WebSocket   bool               `yaml:"websocket,omitempty" desc:"Forward Upgrade/Connection headers so WebSocket (and HMR) connections work"`
+	SSE         bool               `yaml:"sse,omitempty" desc:"Disable buffering and extend timeouts for Server-Sent Events streams"`
+	Proxy       ProxyOptions       `yaml:"proxy,omitempty" desc:"Per-service proxy tuning"`
+	Static      StaticConfig       `yaml:"static,omitempty" desc:"Content settings for static and spa services"`
+	Cache       ServiceCacheConfig `yaml:"cache,omitempty" desc:"Response caching for this service; overrides nginx.cache"`
+	RateLimit   []string           `yaml:"rate_limit,omitempty" desc:"Names of security.rate_limit.policies applied to this service"`
+	BasicAuth   ServiceAuthConfig  `yaml:"basic_auth,omitempty" desc:"Password protection for this service; overrides security.basic_auth"`
+	Access      AccessConfig       `yaml:"access,omitempty" desc:"Client IP allow/deny lists for this service; checked before security.access"`
+	StripPrefix bool               `yaml:"strip_prefix,omitempty" desc:"Remove the location path before proxying, so /api/users reaches the backend as /users"`
+	CORS        CORSConfig         `yaml:"cors,omitempty" desc:"Cross-origin resource sharing policy for this service"`
+	Protocol    string             `yaml:"protocol,omitempty" desc:"Backend protocol; grpc and grpcs use grpc_pass. Defaults to http" enum:"http,https,grpc,grpcs"`
+	Snippet     string             `yaml:"snippet,omitempty" desc:"Raw nginx directives added to this service's location"`
+}
+
+type StaticConfig struct {
+	Root          string            `yaml:"root" desc:"Host directory to serve; relative paths are resolved from the output directory"`
+	Index         string            `yaml:"index,omitempty" desc:"Index file; defaults to index.html"`
+	CacheControl  map[string]string `yaml:"cache_control,omitempty" desc:"Cache-Control value per comma-separated extension list, e.g. 'js,css': 'public, max-age=31536000, immutable'"`
+	Precompressed []string          `yaml:"precompressed,omitempty" desc:"Serve precompressed .gz/.br files next to the originals (br needs an image with ngx_brotli)" enum:"gzip,br"`
+	Autoindex     bool              `yaml:"autoindex,omitempty" desc:"Enable directory listing"`
+}
+
+// Mount is a host path (or named volume) that the nginx container needs in
+// addition to nginx.conf, ssl/ and logs/.
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+	Volume   bool
+}
+
+type ProxyOptions struct {
+	ConnectTimeout      string            `yaml:"connect_timeout,omitempty" desc:"proxy_connect_timeout, e.g. 5s" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+	ReadTimeout         string            `yaml:"read_timeout,omitempty" desc:"proxy_read_timeout, e.g. 60s" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+	SendTimeout         string            `yaml:"send_timeout,omitempty" desc:"proxy_send_timeout, e.g. 60s" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+	Buffering           *bool             `yaml:"buffering,omitempty" desc:"Buffer upstream responses (proxy_buffering)"`
+	RequestBuffering    *bool             `yaml:"request_buffering,omitempty" desc:"Buffer request bodies before proxying (proxy_request_buffering)"`
+	MaxBodySize         string            `yaml:"max_body_size,omitempty" desc:"Maximum request body size, e.g. 100m; 0 disables the check" pattern:"^[0-9]+[kKmMgG]?$"`
+	HTTPVersion         string            `yaml:"http_version,omitempty" desc:"HTTP version used towards the upstream" enum:"1.0,1.1"`
+	NextUpstream        []string          `yaml:"next_upstream,omitempty" desc:"Conditions for retrying on the next upstream server (proxy_next_upstream)" enum:"error,timeout,invalid_header,http_500,http_502,http_503,http_504,http_403,http_404,http_429,non_idempotent,off"`
+	NextUpstreamTries   int               `yaml:"next_upstream_tries,omitempty" desc:"Maximum number of retry attempts; 0 means unlimited" min:"0"`
+	NextUpstreamTimeout string            `yaml:"next_upstream_timeout,omitempty" desc:"Time limit for retries" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+	SetHeaders          map[string]string `yaml:"set_headers,omitempty" desc:"Request headers sent to the upstream (proxy_set_header)"`
+	HideHeaders         []string          `yaml:"hide_headers,omitempty" desc:"Upstream response headers removed before replying"`
+	AddHeaders          map[string]string `yaml:"add_headers,omitempty" desc:"Response headers added for this service"`
 }
 
 type SecurityConfig struct {
-	Enabled       bool              `yaml:"enabled"`
-	Level         string            `yaml:"level"` // strict, balanced, permissive
-	EnableHSTS    bool              `yaml:"enable_hsts"`
-	HSTSMaxAge    int               `yaml:"hsts_max_age"`
-	EnableCSP     bool              `yaml:"enable_csp"`
-	CSPPolicy     string            `yaml:"csp_policy"`
-	CustomHeaders map[string]string `yaml:"custom_headers"`
-	RateLimit     RateLimitConfig   `yaml:"rate_limit"`
+	Enabled        bool              `yaml:"enabled" desc:"Enable security headers"`
+	Level          string            `yaml:"level" desc:"Security header profile" enum:"strict,balanced,permissive"`
+	EnableHSTS     bool              `yaml:"enable_hsts" desc:"Send Strict-Transport-Security"`
+	HSTSMaxAge     int               `yaml:"hsts_max_age" desc:"HSTS max-age in seconds" min:"0"`
+	EnableCSP      bool              `yaml:"enable_csp" desc:"Send Content-Security-Policy"`
+	CSPPolicy      string            `yaml:"csp_policy" desc:"Content-Security-Policy value"`
+	CustomHeaders  map[string]string `yaml:"custom_headers" desc:"Headers that override the security profile"`
+	RateLimit      RateLimitConfig   `yaml:"rate_limit" desc:"Global request rate limit"`
+	BasicAuth      BasicAuthConfig   `yaml:"basic_auth,omitempty" desc:"HTTP basic authentication backed by the project htpasswd file"`
+	Access         AccessConfig      `yaml:"access,omitempty" desc:"Client IP allow/deny lists for every location"`
+	TrustedProxies []string          `yaml:"trusted_proxies,omitempty" desc:"Load balancers whose client IP header is trusted (set_real_ip_from)"`
+	RealIPHeader   string            `yaml:"real_ip_header,omitempty" desc:"Header carrying the client IP from trusted proxies; defaults to X-Forwarded-For"`
 }
 
 type RateLimitConfig struct {
-	Enabled           bool `yaml:"enabled"`
-	RequestsPerMinute int  `yaml:"requests_per_minute"`
-	BurstSize         int  `yaml:"burst_size"`
+	Enabled           bool              `yaml:"enabled" desc:"Enable the global rate limit"`
+	RequestsPerMinute int               `yaml:"requests_per_minute" desc:"Requests per minute per client IP" min:"1"`
+	BurstSize         int               `yaml:"burst_size" desc:"Requests allowed above the rate before rejecting" min:"0"`
+	Status            int               `yaml:"status,omitempty" desc:"Status returned to limited clients; nginx defaults to 503" min:"400" max:"599"`
+	DryRun            bool              `yaml:"dry_run,omitempty" desc:"Log requests that would be limited without rejecting them"`
+	Allowlist         []string          `yaml:"allowlist,omitempty" desc:"IP addresses or CIDR ranges that bypass every limit"`
+	Policies          []RateLimitPolicy `yaml:"policies,omitempty" desc:"Named limits applied to services through their rate_limit list"`
+}
+
+type RateLimitPolicy struct {
+	Name        string `yaml:"name" desc:"Policy name referenced by services" pattern:"^[A-Za-z0-9_]+$"`
+	Key         string `yaml:"key,omitempty" desc:"What is limited: ip (default), header:<Name>, api_key (X-API-Key header) or an nginx variable"`
+	Rate        string `yaml:"rate,omitempty" desc:"Request rate such as 10r/s or 300r/m" pattern:"^[0-9]+r/[sm]$"`
+	Burst       int    `yaml:"burst,omitempty" desc:"Requests allowed above the rate" min:"0"`
+	Delay       bool   `yaml:"delay,omitempty" desc:"Queue burst requests instead of serving them immediately (omits nodelay)"`
+	Connections int    `yaml:"connections,omitempty" desc:"Maximum concurrent connections per key (limit_conn)" min:"1"`
+	Size        string `yaml:"size,omitempty" desc:"Shared memory zone size; defaults to 10m" pattern:"^[0-9]+[kKmMgG]?$"`
 }
 
 type DockerConfig struct {
-	ComposeVersion string `yaml:"compose_version"`
-	NetworkName    string `yaml:"network_name"`
-	NginxImage     string `yaml:"nginx_image"`
+	ComposeVersion  string `yaml:"compose_version" desc:"docker-compose file format version"`
+	NetworkName     string `yaml:"network_name" desc:"Docker network shared with the services"`
+	NginxImage      string `yaml:"nginx_image" desc:"Nginx image used for the container"`
+	ExternalNetwork bool   `yaml:"external_network,omitempty" desc:"Join network_name as an existing network, e.g. one created by the application's compose project, instead of creating it"`
 }
 
 func NewDefaultConfig() *Config {
@@ -109,6 +243,7 @@ func NewDefaultConfig() *Config {
 				Enabled:           false,
 				RequestsPerMinute: 100,
 				BurstSize:         20,
+				Status:            429,
 			},
 		},
 		Docker: DockerConfig{
@@ -124,12 +259,14 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("domain is required")
 	}
 
-	if c.SSL.KeySize < 2048 {
-		return fmt.Errorf("SSL key size must be at least 2048 bits")
+	switch c.SSL.KeySize {
+	case 2048, 3072, 4096:
+	default:
+		return fmt.Errorf("invalid SSL key size %d (must be 2048, 3072, or 4096)", c.SSL.KeySize)
 	}
 
-	if c.SSL.ValidityDays <= 0 {
-		return fmt.Errorf("SSL validity days must be positive")
+	if c.SSL.ValidityDays <= 0 || c.SSL.ValidityDays > 3650 {
+		return fmt.Errorf("SSL validity days must be between 1 and 3650")
 	}
 
 	if c.Nginx.HTTPSPort <= 0 || c.Nginx.HTTPSPort > 65535 {
@@ -140,6 +277,384 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid HTTP port: %d", c.Nginx.HTTPPort)
 	}
 
+	if err := c.validateSites(); err != nil {
+		return err
+	}
+
+	if err := c.validateServiceHosts(); err != nil {
+		return err
+	}
+
+	if err := c.validateUpstreams(); err != nil {
+		return err
+	}
+
+	if err := c.Nginx.Cache.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Security.RateLimit.validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateBasicAuth(); err != nil {
+		return err
+	}
+
+	if err := c.validateAccess(); err != nil {
+		return err
+	}
+
+	if err := c.validateHTTP3(); err != nil {
+		return err
+	}
+
+	if err := c.validateStreams(); err != nil {
+		return err
+	}
+
+	if err := c.validateSnippets(); err != nil {
+		return err
+	}
+
+	if err := c.validateCustomHeaders(); err != nil {
+		return err
+	}
+
+	if err := c.validateLogging(); err != nil {
+		return err
+	}
+
+	if err := validateRouting("nginx", c.Nginx.Redirects, c.Nginx.Rewrites, c.Nginx.CanonicalHost); err != nil {
+		return err
+	}
+	for _, site := range c.Nginx.Sites {
+		if err := validateRouting("site "+site.Name, site.Redirects, site.Rewrites, site.CanonicalHost); err != nil {
+			return err
+		}
+	}
+
+	staticNames := map[string]bool{}
+	corsNames := map[string]string{}
+	for _, service := range c.AllServices() {
+		if err := service.validatePath(); err != nil {
+			return fmt.Errorf("service %s: %w", service.Name, err)
+		}
+
+		if err := service.Proxy.validate(service); err != nil {
+			return fmt.Errorf("service %s: %w", service.Name, err)
+		}
+
+		if err := service.Cache.CacheSettings.validate(); err != nil {
+			return fmt.Errorf("service %s: cache: %w", service.Name, err)
+		}
+		if service.Cache.Enabled != nil && *service.Cache.Enabled && (service.IsStatic() || service.SSE || service.WebSocket) {
+			return fmt.Errorf("service %s: cache.enabled is not supported for websocket, sse, static or spa services", service.Name)
+		}
+
+		for _, name := range service.RateLimit {
+			if c.Security.RateLimit.Policy(name) == nil {
+				return fmt.Errorf("service %s references unknown rate limit policy: %s", service.Name, name)
+			}
+		}
+
+		if err := service.validateProtocol(); err != nil {
+			return fmt.Errorf("service %s: %w", service.Name, err)
+		}
+
+		if err := service.CORS.validate(service); err != nil {
+			return fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		if service.CORS.Enabled() {
+			if other, ok := corsNames[service.CORSVariable()]; ok {
+				if other == service.Name {
+					return fmt.Errorf("duplicate service name with cors: %s", service.Name)
+				}
+				return fmt.Errorf("services %s and %s both use cors and differ only in - and _; rename one", other, service.Name)
+			}
+			corsNames[service.CORSVariable()] = service.Name
+		}
+
+		if service.StripPrefix {
+			path := service.LocationPath()
+			if service.IsStatic() || !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " ~*") {
+				return fmt.Errorf("service %s: strip_prefix needs a proxied service with a plain prefix path", service.Name)
+			}
+		}
+
+		switch service.Type {
+		case "", "proxy":
+		case "static", "spa":
+			if staticNames[service.Name] {
+				return fmt.Errorf("duplicate static service name: %s", service.Name)
+			}
+			staticNames[service.Name] = true
+			if err := service.Static.validate(service); err != nil {
+				return fmt.Errorf("service %s: %w", service.Name, err)
+			}
+		default:
+			return fmt.Errorf("service %s: invalid type %s (must be proxy, static, or spa)", service.Name, service.Type)
+		}
+	}
+
+	return c.validateSchema()
+}
+
+func (s ServiceConfig) validateProtocol() error {
+	switch s.Protocol {
+	case "", "http", "https":
+		return nil
+	case "grpc", "grpcs":
+	default:
+		return fmt.Errorf("invalid protocol %s (must be http, https, grpc, or grpcs)", s.Protocol)
+	}
+
+	if s.IsStatic() {
+		return fmt.Errorf("protocol %s is not supported for %s services", s.Protocol, s.Type)
+	}
+	if s.WebSocket || s.SSE || s.StripPrefix || s.CORS.Enabled() {
+		return fmt.Errorf("websocket, sse, strip_prefix and cors are not supported with protocol %s", s.Protocol)
+	}
+	if s.Cache.Enabled != nil && *s.Cache.Enabled {
+		return fmt.Errorf("caching is not supported with protocol %s", s.Protocol)
+	}
+	if s.Proxy.Buffering != nil || s.Proxy.RequestBuffering != nil || s.Proxy.HTTPVersion != "" {
+		return fmt.Errorf("proxy.buffering, proxy.request_buffering and proxy.http_version do not apply to protocol %s", s.Protocol)
+	}
+	return nil
+}
+
+func (s StaticConfig) validate(service ServiceConfig) error {
+	if s.Root == "" {
+		return fmt.Errorf("static.root is required for %s services", service.Type)
+	}
+
+	path := service.LocationPath()
+	if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " ~*") {
+		return fmt.Errorf("%s services need a plain prefix path, got %q", service.Type, path)
+	}
+
+	if strings.Contains(s.Index, "/") {
+		return fmt.Errorf("static.index must be a file name")
+	}
+
+	for extensions := range s.CacheControl {
+		for _, ext := range strings.Split(extensions, ",") {
+			if !extensionPattern.MatchString(strings.TrimSpace(ext)) {
+				return fmt.Errorf("invalid static.cache_control extension %q", ext)
+			}
+		}
+	}
+
+	for _, encoding := range s.Precompressed {
+		if encoding != "gzip" && encoding != "br" {
+			return fmt.Errorf("invalid static.precompressed encoding %q (must be gzip or br)", encoding)
+		}
+	}
+
+	return nil
+}
+
+func (p ProxyOptions) validate(service ServiceConfig) error {
+	timeouts := map[string]string{
+		"connect_timeout":       p.ConnectTimeout,
+		"read_timeout":          p.ReadTimeout,
+		"send_timeout":          p.SendTimeout,
+		"next_upstream_timeout": p.NextUpstreamTimeout,
+	}
+	for name, value := range timeouts {
+		if value != "" && !durationPattern.MatchString(value) {
+			return fmt.Errorf("invalid proxy.%s %q", name, value)
+		}
+	}
+
+	if p.MaxBodySize != "" && !sizePattern.MatchString(p.MaxBodySize) {
+		return fmt.Errorf("invalid proxy.max_body_size %q", p.MaxBodySize)
+	}
+
+	switch p.HTTPVersion {
+	case "", "1.1":
+	case "1.0":
+		if service.WebSocket || service.SSE {
+			return fmt.Errorf("websocket and sse require proxy.http_version 1.1")
+		}
+	default:
+		return fmt.Errorf("invalid proxy.http_version %q (must be 1.0 or 1.1)", p.HTTPVersion)
+	}
+
+	for _, condition := range p.NextUpstream {
+		if !validNextUpstream[condition] {
+			return fmt.Errorf("invalid proxy.next_upstream condition %q", condition)
+		}
+	}
+
+	if p.NextUpstreamTries < 0 {
+		return fmt.Errorf("proxy.next_upstream_tries must not be negative")
+	}
+
+	if err := validateHeaders("proxy.set_headers", p.SetHeaders); err != nil {
+		return err
+	}
+	if err := validateHeaders("proxy.add_headers", p.AddHeaders); err != nil {
+		return err
+	}
+	for _, name := range p.HideHeaders {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid proxy.hide_headers name %q", name)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateUpstreams() error {
+	upstreams := map[string]bool{}
+	for _, upstream := range c.Nginx.Upstreams {
+		if upstream.Name == "" {
+			return fmt.Errorf("upstream name is required")
+		}
+		if upstreams[upstream.Name] {
+			return fmt.Errorf("duplicate upstream name: %s", upstream.Name)
+		}
+		upstreams[upstream.Name] = true
+
+		if len(upstream.Servers) == 0 {
+			return fmt.Errorf("upstream %s: at least one server is required", upstream.Name)
+		}
+
+		switch upstream.Strategy {
+		case "", "round_robin", "least_conn", "ip_hash":
+			if upstream.HashKey != "" || upstream.Consistent {
+				return fmt.Errorf("upstream %s: hash_key and consistent require the hash strategy", upstream.Name)
+			}
+		case "hash":
+			if upstream.HashKey == "" {
+				return fmt.Errorf("upstream %s: hash strategy requires hash_key", upstream.Name)
+			}
+		default:
+			return fmt.Errorf("upstream %s: invalid strategy %s (must be round_robin, least_conn, ip_hash, or hash)", upstream.Name, upstream.Strategy)
+		}
+
+		if upstream.Keepalive < 0 {
+			return fmt.Errorf("upstream %s: keepalive must not be negative", upstream.Name)
+		}
+
+		for _, server := range upstream.Servers {
+			if server.Address == "" {
+				return fmt.Errorf("upstream %s: server address is required", upstream.Name)
+			}
+			if server.Weight < 0 || server.MaxFails < 0 {
+				return fmt.Errorf("upstream %s: server %s: weight and max_fails must not be negative", upstream.Name, server.Address)
+			}
+			if server.FailTimeout != "" && !durationPattern.MatchString(server.FailTimeout) {
+				return fmt.Errorf("upstream %s: server %s: invalid fail_timeout %q", upstream.Name, server.Address, server.FailTimeout)
+			}
+			if server.Backup && (upstream.Strategy == "hash" || upstream.Strategy == "ip_hash") {
+				return fmt.Errorf("upstream %s: backup servers cannot be used with the %s strategy", upstream.Name, upstream.Strategy)
+			}
+		}
+	}
+
+	for _, service := range c.AllServices() {
+		if service.Upstream != "" && !upstreams[service.Upstream] {
+			return fmt.Errorf("service %s: unknown upstream %s", service.Name, service.Upstream)
+		}
+		// Upstream keepalive needs HTTP/1.1 towards the servers.
+		if upstream := c.Upstream(service.Upstream); upstream != nil && upstream.Keepalive > 0 && service.Proxy.HTTPVersion == "1.0" {
+			return fmt.Errorf("service %s: upstream %s sets keepalive, which requires proxy.http_version 1.1", service.Name, service.Upstream)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) AllServices() []ServiceConfig {
+	services := append([]ServiceConfig{}, c.Nginx.Services...)
+	for _, site := range c.Nginx.Sites {
+		services = append(services, site.Services...)
+	}
+	return services
+}
+
+func (c *Config) Upstream(name string) *UpstreamConfig {
+	for i := range c.Nginx.Upstreams {
+		if c.Nginx.Upstreams[i].Name == name {
+			return &c.Nginx.Upstreams[i]
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateServiceHosts() error {
+	serverNames := map[string]bool{}
+	for _, name := range strings.Fields(c.Nginx.ServerName) {
+		serverNames[name] = true
+	}
+	for _, site := range c.Nginx.Sites {
+		for _, name := range site.ServerNames {
+			serverNames[name] = true
+		}
+		for _, service := range site.Services {
+			if service.Host != "" {
+				return fmt.Errorf("site %s: service %s cannot set host; add a site with that server name instead", site.Name, service.Name)
+			}
+		}
+	}
+
+	for _, service := range c.Nginx.Services {
+		host := service.HostName(c.Project.Domain)
+		if host == "" {
+			continue
+		}
+		if !hostnamePattern.MatchString(host) {
+			return fmt.Errorf("service %s: invalid host %q", service.Name, service.Host)
+		}
+		if serverNames[host] {
+			return fmt.Errorf("service %s: host %s is already used as a server name", service.Name, host)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateSites() error {
+	serverNames := map[string]string{}
+	for _, name := range strings.Fields(c.Nginx.ServerName) {
+		serverNames[name] = "nginx.server_name"
+	}
+
+	siteNames := map[string]bool{}
+	for _, site := range c.Nginx.Sites {
+		if site.Name == "" {
+			return fmt.Errorf("site name is required")
+		}
+		if !serviceNamePattern.MatchString(site.Name) {
+			return fmt.Errorf("invalid site name %q: use letters, digits, '-' or '_'", site.Name)
+		}
+		if siteNames[site.Name] {
+			return fmt.Errorf("duplicate site name: %s", site.Name)
+		}
+		siteNames[site.Name] = true
+
+		if len(site.ServerNames) == 0 {
+			return fmt.Errorf("site %s: at least one server name is required", site.Name)
+		}
+		for _, name := range site.ServerNames {
+			if owner, exists := serverNames[name]; exists {
+				return fmt.Errorf("site %s: server name %s is already used by %s", site.Name, name, owner)
+			}
+			serverNames[name] = "site " + site.Name
+		}
+
+		if (site.Certificate == "") != (site.CertificateKey == "") {
+			return fmt.Errorf("site %s: certificate and certificate_key must be set together", site.Name)
+		}
+
+		if site.SecurityLevel != "" && !ValidSecurityLevel(site.SecurityLevel) {
+			return fmt.Errorf("site %s: invalid security level: %s", site.Name, site.SecurityLevel)
+		}
+	}
+
 	return nil
 }
 
@@ -153,38 +668,204 @@ func (c *Config) AddService(name string, port int, path string) {
 	c.Nginx.Services = append(c.Nginx.Services, service)
 }
 
-func (c *Config) SetSecurityLevel(level string) error {
-	validLevels := map[string]bool{
-		"strict":     true,
-		"balanced":   true,
-		"permissive": true,
+func (s ServiceConfig) HostName(domain string) string {
+	if s.Host == "" || strings.Contains(s.Host, ".") || s.Host == domain {
+		return s.Host
+	}
+	return s.Host + "." + domain
+}
+
+const StaticRootDir = "/usr/share/nginx/keynginx"
+
+func (s ServiceConfig) IsStatic() bool {
+	return s.Type == "static" || s.Type == "spa"
+}
+
+func (s ServiceConfig) IndexFile() string {
+	if s.Static.Index == "" {
+		return "index.html"
+	}
+	return s.Static.Index
+}
+
+// DocumentRoot is the nginx root for a static service. The host directory is
+// mounted below it at the location path so that root, try_files and nested
+// locations resolve without alias.
+func (s ServiceConfig) DocumentRoot() string {
+	return StaticRootDir + "/" + s.Name
+}
+
+func (s ServiceConfig) StaticMountTarget() string {
+	return s.DocumentRoot() + strings.TrimSuffix(s.LocationPath(), "/")
+}
+
+func (c *Config) Mounts() []Mount {
+	var mounts []Mount
+	for _, service := range c.AllServices() {
+		if service.IsStatic() {
+			mounts = append(mounts, Mount{
+				Source:   service.Static.Root,
+				Target:   service.StaticMountTarget(),
+				ReadOnly: true,
+			})
+		}
+	}
+	if c.BasicAuthUsed() {
+		mounts = append(mounts, Mount{
+			Source:   filepath.Dir(HtpasswdFile),
+			Target:   AuthDir,
+			ReadOnly: true,
+		})
 	}
+	if c.CacheEnabled() {
+		mounts = append(mounts, Mount{
+			Source: c.CacheVolume(),
+			Target: CacheDir,
+			Volume: true,
+		})
+	}
+	return mounts
+}
 
-	if !validLevels[level] {
+func (s ServiceConfig) Scheme() string {
+	if s.Protocol == "" {
+		return "http"
+	}
+	return s.Protocol
+}
+
+func (s ServiceConfig) IsGRPC() bool {
+	return s.Protocol == "grpc" || s.Protocol == "grpcs"
+}
+
+// ProxyTarget is the proxy_pass (or grpc_pass) URL. An explicit protocol
+// replaces the scheme of proxy_pass, so switching a service to grpc does not
+// require rewriting its address.
+func (s ServiceConfig) ProxyTarget() string {
+	if s.Upstream != "" {
+		return s.Scheme() + "://" + s.Upstream
+	}
+	if s.ProxyPass != "" {
+		if _, address, ok := strings.Cut(s.ProxyPass, "://"); ok && s.Protocol != "" {
+			return s.Protocol + "://" + address
+		}
+		return s.ProxyPass
+	}
+	return fmt.Sprintf("%s://%s:%d", s.Scheme(), s.Name, s.Port)
+}
+
+func (s ServiceConfig) LocationPath() string {
+	if s.Path == "" {
+		return "/"
+	}
+	return s.Path
+}
+
+var locationModifiers = map[string]bool{"=": true, "~": true, "~*": true, "^~": true}
+
+// LocationArgs splits the path into the arguments of its location block: an
+// optional modifier followed by the URI or regular expression.
+func (s ServiceConfig) LocationArgs() []string {
+	path := s.LocationPath()
+	if modifier, rest, ok := strings.Cut(path, " "); ok && locationModifiers[modifier] {
+		return []string{modifier, strings.TrimSpace(rest)}
+	}
+	return []string{path}
+}
+
+func (s ServiceConfig) validatePath() error {
+	if strings.ContainsAny(s.Path, "\r\n\t") {
+		return fmt.Errorf("path must be a single line")
+	}
+
+	args := s.LocationArgs()
+	target := args[len(args)-1]
+	switch {
+	case target == "":
+		return fmt.Errorf("path %q has a modifier but no URI", s.Path)
+	case len(args) == 1 && !strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "@"):
+		return fmt.Errorf("path %q must start with / or @, or with a modifier (=, ~, ~*, ^~) and a space", s.Path)
+	case len(args) == 2 && (args[0] == "=" || args[0] == "^~") && !strings.HasPrefix(target, "/"):
+		return fmt.Errorf("path %q must be a URI starting with / after %s", s.Path, args[0])
+	case strings.HasPrefix(target, "@") && strings.ContainsAny(target, " {};"):
+		return fmt.Errorf("named location %q must be a single word", s.Path)
+	}
+	return nil
+}
+
+// ServiceHosts returns the distinct host names used for host-based routing,
+// in the order they first appear.
+func (c *Config) ServiceHosts() []string {
+	var hosts []string
+	seen := map[string]bool{}
+	for _, service := range c.Nginx.Services {
+		host := service.HostName(c.Project.Domain)
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+func (s SiteConfig) CertificateFiles() (string, string) {
+	if s.Certificate != "" {
+		return s.Certificate, s.CertificateKey
+	}
+	return filepath.Join(s.Name, "certificate.crt"), filepath.Join(s.Name, "private.key")
+}
+
+func (s SiteConfig) GeneratesCertificate() bool {
+	return s.Certificate == ""
+}
+
+func (s SiteConfig) Security(base SecurityConfig) SecurityConfig {
+	security := base
+	if s.SecurityLevel != "" && s.SecurityLevel != base.Level {
+		security.ApplyLevel(s.SecurityLevel)
+	}
+	return security
+}
+
+func (c *Config) SetSecurityLevel(level string) error {
+	return c.Security.ApplyLevel(level)
+}
+
+func (s *SecurityConfig) ApplyLevel(level string) error {
+	if !ValidSecurityLevel(level) {
 		return fmt.Errorf("invalid security level: %s (must be strict, balanced, or permissive)", level)
 	}
 
-	c.Security.Level = level
+	s.Level = level
 
 	switch level {
 	case "strict":
-		c.Security.EnableHSTS = true
-		c.Security.HSTSMaxAge = 63072000 // 2 years
-		c.Security.EnableCSP = true
-		c.Security.CSPPolicy = "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self';"
+		s.EnableHSTS = true
+		s.HSTSMaxAge = 63072000 // 2 years
+		s.EnableCSP = true
+		s.CSPPolicy = "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self';"
 	case "balanced":
-		c.Security.EnableHSTS = true
-		c.Security.HSTSMaxAge = 31536000 // 1 year
-		c.Security.EnableCSP = true
-		c.Security.CSPPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data: https:;"
+		s.EnableHSTS = true
+		s.HSTSMaxAge = 31536000 // 1 year
+		s.EnableCSP = true
+		s.CSPPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data: https:;"
 	case "permissive":
-		c.Security.EnableHSTS = false
-		c.Security.EnableCSP = false
+		s.EnableHSTS = false
+		s.EnableCSP = false
 	}
 
 	return nil
 }
 
+func ValidSecurityLevel(level string) bool {
+	validLevels := map[string]bool{
+		"strict":     true,
+		"balanced":   true,
+		"permissive": true,
+	}
+	return validLevels[level]
+}
+
 func (c *Config) Save(filename string) error {
 	data, err := yaml.Marshal(c)
 	if err != nil {
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var serviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type CORSConfig struct {
+	Origins       []string `yaml:"origins" desc:"Allowed origins: exact (https://app.example.com), wildcard (https://*.example.com, http://localhost:*), ~regex, or * for any"`
+	Methods       []string `yaml:"methods,omitempty" desc:"Allowed methods; defaults to GET, POST, PUT, PATCH, DELETE, OPTIONS"`
+	Headers       []string `yaml:"headers,omitempty" desc:"Allowed request headers; defaults to Authorization, Content-Type"`
+	ExposeHeaders []string `yaml:"expose_headers,omitempty" desc:"Response headers readable by the browser"`
+	Credentials   bool     `yaml:"credentials,omitempty" desc:"Allow cookies and Authorization headers (Access-Control-Allow-Credentials)"`
+	MaxAge        int      `yaml:"max_age,omitempty" desc:"Seconds browsers may cache the preflight response; defaults to 86400" min:"0"`
+}
+
+func (c CORSConfig) Enabled() bool {
+	return len(c.Origins) > 0
+}
+
+func (c CORSConfig) AllowedMethods() []string {
+	if len(c.Methods) == 0 {
+		return []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	}
+	return c.Methods
+}
+
+func (c CORSConfig) AllowedHeaders() []string {
+	if len(c.Headers) == 0 {
+		return []string{"Authorization", "Content-Type"}
+	}
+	return c.Headers
+}
+
+func (c CORSConfig) MaxAgeOrDefault() int {
+	if c.MaxAge == 0 {
+		return 86400
+	}
+	return c.MaxAge
+}
+
+// CORSVariable is the suffix of the service's origin map variable. nginx
+// variable names cannot contain "-", so it is replaced with "_".
+func (s ServiceConfig) CORSVariable() string {
+	return strings.ReplaceAll(s.Name, "-", "_")
+}
+
+// OriginPattern turns an origins entry into an nginx map key: exact origins
+// are matched literally and wildcards become an anchored regular expression.
+func OriginPattern(origin string) string {
+	if strings.HasPrefix(origin, "~") || !strings.Contains(origin, "*") {
+		return origin
+	}
+
+	parts := strings.Split(origin, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return "~^" + strings.Join(parts, "[^/]+") + "$"
+}
+
+func (c CORSConfig) validate(service ServiceConfig) error {
+	if !c.Enabled() {
+		if len(c.Methods) > 0 || len(c.Headers) > 0 || len(c.ExposeHeaders) > 0 || c.Credentials {
+			return fmt.Errorf("cors.origins is required")
+		}
+		return nil
+	}
+
+	if service.IsStatic() {
+		return fmt.Errorf("cors is only supported for proxied services")
+	}
+	if !serviceNamePattern.MatchString(service.Name) {
+		return fmt.Errorf("cors needs a service name made of letters, digits, - and _")
+	}
+
+	for _, origin := range c.Origins {
+		if origin == "*" {
+			if c.Credentials {
+				return fmt.Errorf("cors origin * cannot be combined with credentials; list the allowed origins instead")
+			}
+			continue
+		}
+		if strings.HasPrefix(origin, "~") {
+			if _, err := regexp.Compile(strings.TrimPrefix(origin, "~")); err != nil {
+				return fmt.Errorf("invalid cors origin pattern %q: %w", origin, err)
+			}
+			continue
+		}
+		if !strings.Contains(origin, "://") || strings.HasSuffix(origin, "/") || strings.ContainsAny(origin, " \"';") {
+			return fmt.Errorf("invalid cors origin %q (expected scheme://host[:port] without a trailing slash)", origin)
+		}
+	}
+
+	for _, method := range c.Methods {
+		if method != strings.ToUpper(method) || !headerNamePattern.MatchString(method) {
+			return fmt.Errorf("invalid cors method %q", method)
+		}
+	}
+	for _, header := range append(append([]string{}, c.Headers...), c.ExposeHeaders...) {
+		if header != "*" && !headerNamePattern.MatchString(header) {
+			return fmt.Errorf("invalid cors header %q", header)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("cors.max_age must not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,452 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Editor modifies a keynginx.yaml file through the yaml.v3 node tree so that
+// comments, key order and formatting of untouched settings survive the edit.
+type Editor struct {
+	path   string
+	doc    *yaml.Node
+	indent int
+}
+
+func OpenEditor(filename string) (*Editor, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %s must contain a YAML mapping", filename)
+	}
+
+	return &Editor{
+		path:   filename,
+		doc:    doc,
+		indent: detectIndent(data),
+	}, nil
+}
+
+func (e *Editor) Path() string {
+	return e.path
+}
+
+func (e *Editor) Config() (*Config, error) {
+	cfg := &Config{}
+	if err := e.doc.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+func (e *Editor) Get(key string) (*yaml.Node, error) {
+	return LookupNode(e.doc.Content[0], key)
+}
+
+func (e *Editor) Set(key, value string) error {
+	parts, err := editKey(key)
+	if err != nil {
+		return err
+	}
+	return e.setNode(parts, parseValueNode(value))
+}
+
+func (e *Editor) SetValue(key string, value interface{}) error {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode value for %s: %w", key, err)
+	}
+
+	parts, err := editKey(key)
+	if err != nil {
+		return err
+	}
+	return e.setNode(parts, node)
+}
+
+func (e *Editor) Remove(key string) error {
+	parts, err := editKey(key)
+	if err != nil {
+		return err
+	}
+	parent, err := LookupNode(e.doc.Content[0], strings.Join(parts[:len(parts)-1], "."))
+	if err != nil {
+		return err
+	}
+
+	last := parts[len(parts)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == last {
+				parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+				return nil
+			}
+		}
+	case yaml.SequenceNode:
+		if i, ok := sequenceIndex(parent, last); ok {
+			parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("key %s not found", key)
+}
+
+func (e *Editor) AddService(name string, port int, path string) error {
+	cfg, err := e.Config()
+	if err != nil {
+		return err
+	}
+
+	for _, service := range cfg.Nginx.Services {
+		if service.Name == name {
+			return fmt.Errorf("service %s already exists", name)
+		}
+	}
+
+	cfg.AddService(name, port, path)
+
+	serviceNode := &yaml.Node{}
+	if err := serviceNode.Encode(cfg.Nginx.Services[len(cfg.Nginx.Services)-1]); err != nil {
+		return fmt.Errorf("failed to encode service: %w", err)
+	}
+
+	services, err := e.ensureNode([]string{"nginx", "services"}, yaml.SequenceNode)
+	if err != nil {
+		return err
+	}
+	services.Content = append(services.Content, serviceNode)
+
+	return nil
+}
+
+func (e *Editor) RemoveService(name string) error {
+	services, err := LookupNode(e.doc.Content[0], "nginx.services")
+	if err != nil || services.Kind != yaml.SequenceNode {
+		return fmt.Errorf("service %s not found", name)
+	}
+
+	for i, item := range services.Content {
+		if mappingValue(item, "name") == name {
+			services.Content = append(services.Content[:i], services.Content[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("service %s not found", name)
+}
+
+func (e *Editor) SetSecurityLevel(level string) error {
+	cfg, err := e.Config()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.SetSecurityLevel(level); err != nil {
+		return err
+	}
+
+	type update struct {
+		key   string
+		value interface{}
+	}
+
+	updates := []update{
+		{"security.level", cfg.Security.Level},
+		{"security.enable_hsts", cfg.Security.EnableHSTS},
+		{"security.enable_csp", cfg.Security.EnableCSP},
+	}
+	if cfg.Security.EnableHSTS {
+		updates = append(updates, update{"security.hsts_max_age", cfg.Security.HSTSMaxAge})
+	}
+	if cfg.Security.EnableCSP {
+		updates = append(updates, update{"security.csp_policy", cfg.Security.CSPPolicy})
+	}
+
+	for _, update := range updates {
+		if err := e.SetValue(update.key, update.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Editor) SetSchemaModeline(schemaPath string) {
+	modeline := "# yaml-language-server: $schema=" + schemaPath
+
+	var lines []string
+	for _, line := range strings.Split(e.doc.HeadComment, "\n") {
+		if line != "" && !strings.Contains(line, "yaml-language-server:") {
+			lines = append(lines, line)
+		}
+	}
+	e.doc.HeadComment = strings.Join(append([]string{modeline}, lines...), "\n")
+}
+
+func (e *Editor) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(e.indent)
+	if err := encoder.Encode(e.doc); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *Editor) Save() error {
+	data, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return os.WriteFile(e.path, data, 0644)
+}
+
+// LookupNode resolves a dotted key such as "nginx.services.api.port" against a
+// mapping node. Sequence elements are addressed by their "name" field or index.
+func LookupNode(root *yaml.Node, key string) (*yaml.Node, error) {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	node := root
+	for _, part := range splitKey(key) {
+		next := childNode(node, part)
+		if next == nil {
+			return nil, fmt.Errorf("key %s not found", key)
+		}
+		node = next
+	}
+
+	return node, nil
+}
+
+func (e *Editor) setNode(parts []string, value *yaml.Node) error {
+	parent, err := e.ensureNode(parts[:len(parts)-1], yaml.MappingNode)
+	if err != nil {
+		return err
+	}
+
+	last := parts[len(parts)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == last {
+				replaceNode(parent.Content[i+1], value)
+				return nil
+			}
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: last}
+		parent.Content = append(parent.Content, key, value)
+		return nil
+	case yaml.SequenceNode:
+		if i, ok := sequenceIndex(parent, last); ok {
+			replaceNode(parent.Content[i], value)
+			return nil
+		}
+		return fmt.Errorf("no element %s in %s", last, strings.Join(parts[:len(parts)-1], "."))
+	}
+
+	return fmt.Errorf("cannot set %s: parent is not a mapping", strings.Join(parts, "."))
+}
+
+func (e *Editor) ensureNode(parts []string, kind yaml.Kind) (*yaml.Node, error) {
+	node := e.doc.Content[0]
+	for i, part := range parts {
+		normalizeEmpty(node, yaml.MappingNode)
+		next := childNode(node, part)
+		if next == nil {
+			if node.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("no element %s in %s", part, strings.Join(parts[:i], "."))
+			}
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if i == len(parts)-1 && kind == yaml.SequenceNode {
+				next = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}
+			node.Content = append(node.Content, key, next)
+		}
+		node = next
+	}
+
+	normalizeEmpty(node, kind)
+	return node, nil
+}
+
+// normalizeEmpty turns an empty value ("services:" or "services: []") into a
+// block collection of the given kind so items can be appended to it.
+func normalizeEmpty(node *yaml.Node, kind yaml.Kind) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		node.Kind = kind
+		node.Tag = ""
+		node.Value = ""
+	}
+	if node.Kind == kind && len(node.Content) == 0 {
+		node.Style = 0
+	}
+}
+
+func childNode(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, ok := sequenceIndex(node, key); ok {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+func sequenceIndex(node *yaml.Node, key string) (int, bool) {
+	for i, item := range node.Content {
+		if mappingValue(item, "name") == key {
+			return i, true
+		}
+	}
+
+	if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+		return i, true
+	}
+
+	return 0, false
+}
+
+func mappingValue(node *yaml.Node, key string) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// replaceNode swaps the value in place, keeping the comments attached to the
+// original node.
+func replaceNode(target, value *yaml.Node) {
+	headComment, lineComment, footComment := target.HeadComment, target.LineComment, target.FootComment
+	*target = *value
+	if target.HeadComment == "" {
+		target.HeadComment = headComment
+	}
+	if target.LineComment == "" {
+		target.LineComment = lineComment
+	}
+	if target.FootComment == "" {
+		target.FootComment = footComment
+	}
+}
+
+// parseValueNode infers the YAML type of a command-line value. Scalars that
+// YAML reads back unchanged (or that are quoted) and explicit flow collections
+// ("[a, b]", "{k: v}") are parsed; anything else is kept as a plain string, so
+// values like CSP policies or text containing " #" are stored exactly.
+func parseValueNode(value string) *yaml.Node {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(value), doc); err == nil && len(doc.Content) > 0 && !hasComments(doc) {
+		node := doc.Content[0]
+		trimmed := strings.TrimSpace(value)
+		if node.Kind == yaml.ScalarNode {
+			quoted := node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0
+			if node.Value == value || quoted && trimmed == value {
+				return node
+			}
+		} else if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+			blockStyle(node)
+			return node
+		}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func hasComments(node *yaml.Node) bool {
+	if node.HeadComment != "" || node.LineComment != "" || node.FootComment != "" {
+		return true
+	}
+	for _, child := range node.Content {
+		if hasComments(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func blockStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// editKey splits a key that is about to be set or removed, which needs at
+// least one non-empty part.
+func editKey(key string) ([]string, error) {
+	parts := splitKey(key)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("key must not be empty")
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid key %q: empty part", key)
+		}
+	}
+	return parts, nil
+}
+
+func splitKey(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return strings.Split(key, ".")
+}
+
+func detectIndent(data []byte) int {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		return len(line) - len(trimmed)
+	}
+	return 4
+}
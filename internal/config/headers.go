@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateHeaders checks header names and values. Values are quoted when the
+// configuration is printed, so quotes and semicolons are safe; control
+// characters and a "$" that does not start a variable name are not, since
+// nginx rejects them.
+func validateHeaders(field string, headers map[string]string) error {
+	for name, value := range headers {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid %s name %q", field, name)
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("invalid %s value for %s: control characters are not allowed", field, name)
+		}
+		if !validVariables(value) {
+			return fmt.Errorf("invalid %s value for %s: '$' must start a variable such as $host", field, name)
+		}
+	}
+	return nil
+}
+
+func validVariables(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' {
+			continue
+		}
+		rest := value[i+1:]
+		if strings.HasPrefix(rest, "{") {
+			end := strings.IndexByte(rest, '}')
+			if end < 2 || !isVariableName(rest[1:end]) {
+				return false
+			}
+			continue
+		}
+		if rest == "" || !isVariableName(rest[:1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVariableName(name string) bool {
+	for _, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func (c *Config) validateCustomHeaders() error {
+	if err := validateHeaders("nginx.custom_headers", c.Nginx.CustomHeaders); err != nil {
+		return err
+	}
+	if err := validateHeaders("security.custom_headers", c.Security.CustomHeaders); err != nil {
+		return err
+	}
+	for _, site := range c.Nginx.Sites {
+		if err := validateHeaders("custom_headers", site.CustomHeaders); err != nil {
+			return fmt.Errorf("site %s: %w", site.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import "fmt"
+
+// LoggingConfig selects the access log format and which logs are written.
+type LoggingConfig struct {
+	Format     string `yaml:"format,omitempty" desc:"Access log format: combined (default), json, ecs (Elastic Common Schema JSON) or custom" enum:"combined,json,ecs,custom"`
+	Custom     string `yaml:"custom,omitempty" desc:"log_format pattern used by format custom; a pattern starting with { is escaped as JSON"`
+	Access     *bool  `yaml:"access,omitempty" desc:"Write the access log; defaults to true"`
+	ErrorLevel string `yaml:"error_level,omitempty" desc:"Minimum error log severity; defaults to warn" enum:"debug,info,notice,warn,error,crit,alert,emerg"`
+	PerService bool   `yaml:"per_service,omitempty" desc:"Also write each service's requests to logs/<service>.access.log, or <site>.<service>.access.log for site services"`
+}
+
+func (l LoggingConfig) FormatOrDefault() string {
+	if l.Format == "" {
+		return "combined"
+	}
+	return l.Format
+}
+
+func (l LoggingConfig) AccessEnabled() bool {
+	return l.Access == nil || *l.Access
+}
+
+func (l LoggingConfig) ErrorLevelOrDefault() string {
+	if l.ErrorLevel == "" {
+		return "warn"
+	}
+	return l.ErrorLevel
+}
+
+func (c *Config) validateLogging() error {
+	logging := c.Logging
+	switch logging.FormatOrDefault() {
+	case "combined", "json", "ecs":
+		if logging.Custom != "" {
+			return fmt.Errorf("logging.custom requires logging.format: custom")
+		}
+	case "custom":
+		if logging.Custom == "" {
+			return fmt.Errorf("logging.format custom requires logging.custom")
+		}
+	default:
+		return fmt.Errorf("invalid logging.format: %s (expected combined, json, ecs or custom)", logging.Format)
+	}
+
+	switch logging.ErrorLevelOrDefault() {
+	case "debug", "info", "notice", "warn", "error", "crit", "alert", "emerg":
+	default:
+		return fmt.Errorf("invalid logging.error_level: %s", logging.ErrorLevel)
+	}
+
+	if logging.PerService {
+		if !logging.AccessEnabled() {
+			return fmt.Errorf("logging.per_service requires the access log")
+		}
+		for _, service := range c.AllServices() {
+			if !serviceNamePattern.MatchString(service.Name) {
+				return fmt.Errorf("logging.per_service needs service names made of letters, digits, - and _ (service %s)", service.Name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func OverlayPath(filename, env string) string {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	return fmt.Sprintf("%s.%s%s", base, env, ext)
+}
+
+func LoadConfigWithEnv(filename, env string) (*Config, error) {
+	if env == "" {
+		return LoadConfig(filename)
+	}
+
+	base, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	overlayFile := OverlayPath(filename, env)
+	overlay, err := os.ReadFile(overlayFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("overlay for environment %q not found: %s", env, overlayFile)
+		}
+		return nil, fmt.Errorf("failed to read overlay file: %w", err)
+	}
+
+	return ParseConfigWithOverlay(base, overlay)
+}
+
+func ParseConfigWithOverlay(base, overlay []byte) (*Config, error) {
+	baseValues := map[string]interface{}{}
+	if err := yaml.Unmarshal(base, &baseValues); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	overlayValues := map[string]interface{}{}
+	if err := yaml.Unmarshal(overlay, &overlayValues); err != nil {
+		return nil, fmt.Errorf("failed to parse overlay file: %w", err)
+	}
+
+	merged, err := yaml.Marshal(MergeMaps(baseValues, overlayValues))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged config: %w", err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(merged, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse merged config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// MergeMaps layers overlay on top of base:
+//   - mappings are merged key by key, recursively
+//   - non-empty lists whose items are all mappings with a "name" key are merged
+//     by name; matching items are merged recursively, new items are appended
+//   - any other value (scalars, plain lists) in the overlay replaces the base
+//     value; an empty list such as "services: []" clears the base list
+//   - an explicit null in the overlay removes the key from the result
+func MergeMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range overlay {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = mergeValues(result[key], value)
+	}
+
+	return result
+}
+
+func mergeValues(base, overlay interface{}) interface{} {
+	switch overlayValue := overlay.(type) {
+	case map[string]interface{}:
+		if baseValue, ok := base.(map[string]interface{}); ok {
+			return MergeMaps(baseValue, overlayValue)
+		}
+	case []interface{}:
+		if baseValue, ok := base.([]interface{}); ok && isNamedList(baseValue) && isNamedList(overlayValue) {
+			return mergeNamedLists(baseValue, overlayValue)
+		}
+	}
+	return overlay
+}
+
+func isNamedList(items []interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeNamedLists(base, overlay []interface{}) []interface{} {
+	result := make([]interface{}, len(base))
+	copy(result, base)
+
+	index := make(map[string]int, len(base))
+	for i, item := range base {
+		index[item.(map[string]interface{})["name"].(string)] = i
+	}
+
+	for _, item := range overlay {
+		m := item.(map[string]interface{})
+		name := m["name"].(string)
+		if i, exists := index[name]; exists {
+			result[i] = MergeMaps(result[i].(map[string]interface{}), m)
+			continue
+		}
+		index[name] = len(result)
+		result = append(result, m)
+	}
+
+	return result
+}
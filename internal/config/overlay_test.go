@@ -0,0 +1,110 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeMaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		overlay string
+		want    string
+	}{
+		{
+			name:    "nested mappings merge key by key",
+			base:    "nginx: {http_port: 80, https_port: 443}",
+			overlay: "nginx: {https_port: 8443}",
+			want:    "nginx: {http_port: 80, https_port: 8443}",
+		},
+		{
+			name: "named lists merge by name",
+			base: `
+services:
+  - {name: web, port: 3000, path: /}
+  - {name: api, port: 8000, path: /api}`,
+			overlay: `
+services:
+  - {name: api, port: 9000}
+  - {name: admin, port: 4000, path: /admin}`,
+			want: `
+services:
+  - {name: web, port: 3000, path: /}
+  - {name: api, port: 9000, path: /api}
+  - {name: admin, port: 4000, path: /admin}`,
+		},
+		{
+			name: "named items merge recursively",
+			base: `
+services:
+  - name: api
+    proxy: {read_timeout: 60s, set_headers: {X-A: a}}`,
+			overlay: `
+services:
+  - name: api
+    proxy: {set_headers: {X-B: b}}`,
+			want: `
+services:
+  - name: api
+    proxy: {read_timeout: 60s, set_headers: {X-A: a, X-B: b}}`,
+		},
+		{
+			name:    "plain lists are replaced",
+			base:    "security: {trusted_proxies: [10.0.0.0/8, 172.16.0.0/12]}",
+			overlay: "security: {trusted_proxies: [192.168.0.0/16]}",
+			want:    "security: {trusted_proxies: [192.168.0.0/16]}",
+		},
+		{
+			name:    "null deletes a key",
+			base:    "nginx: {server_name: example.com, custom_headers: {X-Env: dev}}",
+			overlay: "nginx: {custom_headers: null}",
+			want:    "nginx: {server_name: example.com}",
+		},
+		{
+			name:    "null deletes a key inside a named item",
+			base:    "services: [{name: api, upstream: pool, port: 8000}]",
+			overlay: "services: [{name: api, upstream: null}]",
+			want:    "services: [{name: api, port: 8000}]",
+		},
+		{
+			name:    "empty list clears the base list",
+			base:    "services: [{name: web, port: 3000}, {name: api, port: 8000}]",
+			overlay: "services: []",
+			want:    "services: []",
+		},
+		{
+			name:    "named list replaces a plain base list",
+			base:    "streams: [postgres]",
+			overlay: "streams: [{name: redis, listen: 6379}]",
+			want:    "streams: [{name: redis, listen: 6379}]",
+		},
+	}
+
+	for _, tt := range tests {
+		base, overlay, want := decodeMap(t, tt.base), decodeMap(t, tt.overlay), decodeMap(t, tt.want)
+		if got := MergeMaps(base, overlay); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: MergeMaps() = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestMergeMapsKeepsBase(t *testing.T) {
+	base := decodeMap(t, "services: [{name: api, port: 8000}]")
+	MergeMaps(base, decodeMap(t, "services: [{name: api, port: 9000}]"))
+
+	if want := decodeMap(t, "services: [{name: api, port: 8000}]"); !reflect.DeepEqual(base, want) {
+		t.Errorf("base changed to %v", base)
+	}
+}
+
+func decodeMap(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(data), &values); err != nil {
+		t.Fatalf("invalid YAML %q: %v", data, err)
+	}
+	return values
+}
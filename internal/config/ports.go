@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type PortMapping struct {
+	HostPort      int
+	ContainerPort int
+	Protocol      string
+}
+
+// String formats the mapping as a Docker port spec, e.g. 8443:8443/udp.
+func (p PortMapping) String() string {
+	spec := fmt.Sprintf("%d:%d", p.HostPort, p.ContainerPort)
+	if p.Protocol != "" && p.Protocol != "tcp" {
+		spec += "/" + p.Protocol
+	}
+	return spec
+}
+
+// PublishedPorts lists every port the container publishes. nginx listens on
+// the configured ports inside the container too, so host and container ports
+// are the same and redirects built from the listen port stay valid.
+func (c *Config) PublishedPorts() []PortMapping {
+	ports := []PortMapping{
+		{HostPort: c.Nginx.HTTPSPort, ContainerPort: c.Nginx.HTTPSPort, Protocol: "tcp"},
+		{HostPort: c.Nginx.HTTPPort, ContainerPort: c.Nginx.HTTPPort, Protocol: "tcp"},
+	}
+	if c.Nginx.HTTP3 {
+		ports = append(ports, PortMapping{HostPort: c.Nginx.HTTPSPort, ContainerPort: c.Nginx.HTTPSPort, Protocol: "udp"})
+	}
+	for _, stream := range c.Nginx.Streams {
+		ports = append(ports, PortMapping{HostPort: stream.Listen, ContainerPort: stream.Listen, Protocol: stream.TransportProtocol()})
+	}
+	return ports
+}
+
+var imageVersionPattern = regexp.MustCompile(`^(\d+)\.(\d+)`)
+
+// NginxImageVersion extracts major.minor from an image tag such as
+// nginx:1.27-alpine. ok is false for tags without a version (latest, alpine,
+// stable, mainline), which track current releases.
+func NginxImageVersion(image string) (major, minor int, ok bool) {
+	name := image
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	tag := ""
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		tag = name[i+1:]
+	}
+
+	match := imageVersionPattern.FindStringSubmatch(tag)
+	if match == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(match[1])
+	minor, _ = strconv.Atoi(match[2])
+	return major, minor, true
+}
+
+func (c *Config) validateHTTP3() error {
+	if !c.Nginx.HTTP3 {
+		return nil
+	}
+
+	if major, minor, ok := NginxImageVersion(c.Docker.NginxImage); ok && (major < 1 || major == 1 && minor < 25) {
+		return fmt.Errorf("nginx.http3 requires nginx 1.25 or newer, but docker.nginx_image is %s", c.Docker.NginxImage)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ratePattern       = regexp.MustCompile(`^[0-9]+r/[sm]$`)
+	policyNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+func (r *RateLimitConfig) Policy(name string) *RateLimitPolicy {
+	for i := range r.Policies {
+		if r.Policies[i].Name == name {
+			return &r.Policies[i]
+		}
+	}
+	return nil
+}
+
+// KeyVariable translates the policy key into the nginx variable the zone is
+// keyed on.
+func (p RateLimitPolicy) KeyVariable() string {
+	switch {
+	case p.Key == "" || p.Key == "ip":
+		return "$binary_remote_addr"
+	case p.Key == "api_key":
+		return "$http_x_api_key"
+	case strings.HasPrefix(p.Key, "header:"):
+		name := strings.TrimPrefix(p.Key, "header:")
+		return "$http_" + strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	}
+	return p.Key
+}
+
+func (r RateLimitConfig) validate() error {
+	if r.Status != 0 && (r.Status < 400 || r.Status > 599) {
+		return fmt.Errorf("invalid rate limit status: %d (must be 400-599)", r.Status)
+	}
+
+	for _, entry := range r.Allowlist {
+		if !ValidAddress(entry) {
+			return fmt.Errorf("invalid rate limit allowlist entry: %s", entry)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, policy := range r.Policies {
+		if !policyNamePattern.MatchString(policy.Name) {
+			return fmt.Errorf("invalid rate limit policy name: %q", policy.Name)
+		}
+		if names[policy.Name] {
+			return fmt.Errorf("duplicate rate limit policy: %s", policy.Name)
+		}
+		names[policy.Name] = true
+
+		if policy.Rate == "" && policy.Connections == 0 {
+			return fmt.Errorf("rate limit policy %s needs a rate or connections", policy.Name)
+		}
+		if policy.Rate != "" && !ratePattern.MatchString(policy.Rate) {
+			return fmt.Errorf("rate limit policy %s: invalid rate %q (e.g. 10r/s)", policy.Name, policy.Rate)
+		}
+		if policy.Burst < 0 || policy.Connections < 0 {
+			return fmt.Errorf("rate limit policy %s: burst and connections must not be negative", policy.Name)
+		}
+		if policy.Size != "" && !sizePattern.MatchString(policy.Size) {
+			return fmt.Errorf("rate limit policy %s: invalid size %q", policy.Name, policy.Size)
+		}
+
+		key := policy.KeyVariable()
+		if !strings.HasPrefix(key, "$") || strings.ContainsAny(key, " ;") {
+			return fmt.Errorf("rate limit policy %s: invalid key %q (use ip, api_key, header:<Name> or a $variable)", policy.Name, policy.Key)
+		}
+		if strings.HasPrefix(policy.Key, "header:") && !headerNamePattern.MatchString(strings.TrimPrefix(policy.Key, "header:")) {
+			return fmt.Errorf("rate limit policy %s: invalid header in key %q", policy.Name, policy.Key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type RedirectRule struct {
+	From          string `yaml:"from" desc:"Path to match: an exact path, a prefix ending in / or a regular expression"`
+	To            string `yaml:"to" desc:"Target path or URL; regex captures such as $1 may be used, prefix matches append the rest of the path"`
+	Match         string `yaml:"match,omitempty" desc:"How from is matched; defaults to exact" enum:"exact,prefix,regex"`
+	Code          int    `yaml:"code,omitempty" desc:"Redirect status; defaults to 301" enum:"301,302,307,308"`
+	PreserveQuery *bool  `yaml:"preserve_query,omitempty" desc:"Append the original query string; defaults to true"`
+}
+
+type RewriteRule struct {
+	From string `yaml:"from" desc:"Regular expression matched against the request URI"`
+	To   string `yaml:"to" desc:"Replacement URI; captures such as $1 may be used"`
+	Flag string `yaml:"flag,omitempty" desc:"last re-runs location matching (default), break stays in the current location" enum:"last,break"`
+}
+
+func (r RedirectRule) MatchType() string {
+	if r.Match == "" {
+		return "exact"
+	}
+	return r.Match
+}
+
+func (r RedirectRule) StatusCode() int {
+	if r.Code == 0 {
+		return 301
+	}
+	return r.Code
+}
+
+func (r RedirectRule) KeepsQuery() bool {
+	return r.PreserveQuery == nil || *r.PreserveQuery
+}
+
+func (r RewriteRule) FlagOrDefault() string {
+	if r.Flag == "" {
+		return "last"
+	}
+	return r.Flag
+}
+
+// HTTPSPortSuffix is appended to redirect hosts so that redirects keep a
+// non-standard HTTPS port such as 8443.
+func (n NginxConfig) HTTPSPortSuffix() string {
+	if n.HTTPSPort == 443 {
+		return ""
+	}
+	return fmt.Sprintf(":%d", n.HTTPSPort)
+}
+
+func validateRouting(scope string, redirects []RedirectRule, rewrites []RewriteRule, canonical string) error {
+	for _, redirect := range redirects {
+		if redirect.From == "" || redirect.To == "" {
+			return fmt.Errorf("%s.redirects: from and to are required", scope)
+		}
+		if strings.ContainsAny(redirect.From+redirect.To, " ;{}\"'") {
+			return fmt.Errorf("%s.redirects: %s -> %s must not contain spaces, quotes, braces or semicolons", scope, redirect.From, redirect.To)
+		}
+		switch redirect.MatchType() {
+		case "exact", "prefix":
+			if !strings.HasPrefix(redirect.From, "/") {
+				return fmt.Errorf("%s.redirects: %s must start with /", scope, redirect.From)
+			}
+		case "regex":
+		default:
+			return fmt.Errorf("%s.redirects: invalid match %q (must be exact, prefix or regex)", scope, redirect.Match)
+		}
+		switch redirect.StatusCode() {
+		case 301, 302, 307, 308:
+		default:
+			return fmt.Errorf("%s.redirects: invalid code %d (must be 301, 302, 307 or 308)", scope, redirect.Code)
+		}
+	}
+
+	for _, rewrite := range rewrites {
+		if rewrite.From == "" || rewrite.To == "" {
+			return fmt.Errorf("%s.rewrites: from and to are required", scope)
+		}
+		if strings.ContainsAny(rewrite.From+rewrite.To, " ;{}\"'") {
+			return fmt.Errorf("%s.rewrites: %s -> %s must not contain spaces, quotes, braces or semicolons", scope, rewrite.From, rewrite.To)
+		}
+		if flag := rewrite.FlagOrDefault(); flag != "last" && flag != "break" {
+			return fmt.Errorf("%s.rewrites: invalid flag %q (must be last or break)", scope, rewrite.Flag)
+		}
+	}
+
+	switch canonical {
+	case "", "www", "non-www":
+	default:
+		return fmt.Errorf("%s.canonical_host: invalid value %q (must be www or non-www)", scope, canonical)
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const SchemaID = "https://github.com/sinhaparth5/keynginx/keynginx.schema.json"
+
+// Schema is the subset of JSON Schema (draft-07) that KeyNginx generates from
+// the Config struct tree and understands when validating keynginx.yaml.
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+func GenerateSchema() *Schema {
+	schema := schemaForType(reflect.TypeOf(Config{}))
+	schema.SchemaURI = "http://json-schema.org/draft-07/schema#"
+	schema.ID = SchemaID
+	schema.Title = "KeyNginx project configuration"
+	schema.Description = "keynginx.yaml and keynginx.<env>.yaml overlay files"
+	return schema
+}
+
+func schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaForType(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{
+			Type:                 "object",
+			Properties:           map[string]*Schema{},
+			AdditionalProperties: false,
+		}
+		addStructProperties(schema, t)
+		return schema
+	}
+
+	return &Schema{}
+}
+
+func addStructProperties(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline := yamlFieldName(field)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			addStructProperties(schema, field.Type)
+			continue
+		}
+
+		property := schemaForType(field.Type)
+		property.Description = field.Tag.Get("desc")
+		if pattern := field.Tag.Get("pattern"); pattern != "" {
+			property.Pattern = pattern
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			target := property
+			if property.Type == "array" {
+				target = property.Items
+			}
+			target.Enum = enumValues(enum, target.Type)
+		}
+		if min := field.Tag.Get("min"); min != "" {
+			property.Minimum = parseBound(min)
+		}
+		if max := field.Tag.Get("max"); max != "" {
+			property.Maximum = parseBound(max)
+		}
+
+		schema.Properties[name] = property
+	}
+}
+
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	parts := strings.Split(tag, ",")
+	for _, option := range parts[1:] {
+		if option == "inline" {
+			return "", true
+		}
+	}
+	if parts[0] == "" {
+		return strings.ToLower(field.Name), false
+	}
+	return parts[0], false
+}
+
+func enumValues(tag, kind string) []interface{} {
+	var values []interface{}
+	for _, value := range strings.Split(tag, ",") {
+		if kind == "integer" {
+			if n, err := strconv.Atoi(value); err == nil {
+				values = append(values, n)
+				continue
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func parseBound(value string) *float64 {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// ValidateYAML checks raw keynginx.yaml content against the schema and returns
+// every violation found, so typos in keys are reported instead of ignored.
+func (s *Schema) ValidateYAML(data []byte) ([]SchemaError, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return s.Validate(value), nil
+}
+
+// validateSchema checks the loaded configuration against the generated
+// schema, so 'keynginx config validate' never rejects a configuration that
+// generate and up accept.
+func (c *Config) validateSchema() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	errs, err := GenerateSchema().ValidateYAML(data)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (s *Schema) Validate(value interface{}) []SchemaError {
+	var errs []SchemaError
+	s.validate("", value, &errs)
+	return errs
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *[]SchemaError) {
+	if value == nil {
+		return
+	}
+
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected an object, got %s", describeValue(value))
+			return
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := joinSchemaPath(path, key)
+			if property, ok := s.Properties[key]; ok {
+				property.validate(childPath, object[key], errs)
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case *Schema:
+				additional.validate(childPath, object[key], errs)
+			case bool:
+				if !additional {
+					*errs = append(*errs, SchemaError{Path: childPath, Message: "unknown setting"})
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("expected a list, got %s", describeValue(value))
+			return
+		}
+		for i, item := range items {
+			if s.Items != nil {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("expected a string, got %s (quote the value)", describeValue(value))
+			return
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+				fail("%q does not match pattern %s", str, s.Pattern)
+			}
+		}
+	case "integer":
+		n, ok := numericValue(value)
+		if !ok || n != math.Trunc(n) {
+			fail("expected an integer, got %s", describeValue(value))
+			return
+		}
+		s.validateRange(n, fail)
+	case "number":
+		n, ok := numericValue(value)
+		if !ok {
+			fail("expected a number, got %s", describeValue(value))
+			return
+		}
+		s.validateRange(n, fail)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected true or false, got %s", describeValue(value))
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		options := make([]string, len(s.Enum))
+		for i, option := range s.Enum {
+			options[i] = fmt.Sprint(option)
+		}
+		fail("must be one of %s", strings.Join(options, ", "))
+	}
+}
+
+func (s *Schema) validateRange(n float64, fail func(string, ...interface{})) {
+	if s.Minimum != nil && n < *s.Minimum {
+		fail("must be at least %v", *s.Minimum)
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		fail("must be at most %v", *s.Maximum)
+	}
+}
+
+func numericValue(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "a list"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
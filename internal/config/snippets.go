@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TemplatesDir holds per-project template overrides, relative to the
+// project directory.
+const TemplatesDir = "templates"
+
+// SnippetsConfig holds raw nginx directives injected at the template hook
+// points. They are copied verbatim, so they survive regeneration without a
+// full template override.
+type SnippetsConfig struct {
+	HTTP     string `yaml:"http,omitempty" desc:"Raw directives appended to the http block"`
+	Server   string `yaml:"server,omitempty" desc:"Raw directives added to every HTTPS server block"`
+	Location string `yaml:"location,omitempty" desc:"Raw directives added to every service location"`
+	Stream   string `yaml:"stream,omitempty" desc:"Raw directives appended to the stream block"`
+}
+
+func (c *Config) validateSnippets() error {
+	snippets := map[string]string{
+		"http":     c.Nginx.Snippets.HTTP,
+		"server":   c.Nginx.Snippets.Server,
+		"location": c.Nginx.Snippets.Location,
+		"stream":   c.Nginx.Snippets.Stream,
+	}
+	for name, snippet := range snippets {
+		if err := validateSnippet(snippet); err != nil {
+			return fmt.Errorf("nginx.snippets.%s: %w", name, err)
+		}
+	}
+
+	if c.Nginx.Snippets.Stream != "" && len(c.Nginx.Streams) == 0 {
+		return fmt.Errorf("nginx.snippets.stream requires at least one stream")
+	}
+
+	for _, service := range c.AllServices() {
+		if err := validateSnippet(service.Snippet); err != nil {
+			return fmt.Errorf("service %s: snippet: %w", service.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateSnippet rejects snippets whose braces do not balance, which would
+// close the surrounding block and corrupt the rest of the generated file.
+func validateSnippet(snippet string) error {
+	depth := 0
+	for _, line := range strings.Split(snippet, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth < 0 {
+			return fmt.Errorf("unbalanced '}'")
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced '{'")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var streamNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// StreamConfig is a raw TCP or UDP service proxied in the nginx stream block,
+// e.g. Postgres, Redis or MQTT.
+type StreamConfig struct {
+	Name           string            `yaml:"name" desc:"Stream name" pattern:"^[A-Za-z0-9_-]+$"`
+	Listen         int               `yaml:"listen" desc:"Port nginx listens on and publishes on the host" min:"1" max:"65535"`
+	Protocol       string            `yaml:"protocol,omitempty" desc:"Transport protocol; defaults to tcp" enum:"tcp,udp"`
+	Port           int               `yaml:"port,omitempty" desc:"Backend port on the container with the stream's name" min:"1" max:"65535"`
+	ProxyPass      string            `yaml:"proxy_pass,omitempty" desc:"Backend as host:port; overrides name and port"`
+	TLS            bool              `yaml:"tls,omitempty" desc:"Terminate TLS with the project certificate and forward plain TCP to the backend"`
+	SNIRoutes      map[string]string `yaml:"sni_routes,omitempty" desc:"Route TLS connections by server name to host:port backends without decrypting them (ssl_preread)"`
+	ConnectTimeout string            `yaml:"connect_timeout,omitempty" desc:"Timeout for connecting to the backend; nginx default 60s" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+	Timeout        string            `yaml:"timeout,omitempty" desc:"Close the session after this long without traffic; nginx default 10m" pattern:"^[0-9]+(ms|s|m|h|d)?$"`
+}
+
+func (s StreamConfig) TransportProtocol() string {
+	if s.Protocol == "" {
+		return "tcp"
+	}
+	return s.Protocol
+}
+
+// Backend is the default host:port connections are forwarded to. SNI routed
+// streams use it for server names without a route.
+func (s StreamConfig) Backend() string {
+	if s.ProxyPass != "" {
+		return s.ProxyPass
+	}
+	if s.Port > 0 {
+		return fmt.Sprintf("%s:%d", s.Name, s.Port)
+	}
+	return ""
+}
+
+func (c *Config) validateStreams() error {
+	used := map[string]string{
+		fmt.Sprintf("%d/tcp", c.Nginx.HTTPSPort): "nginx.https_port",
+		fmt.Sprintf("%d/tcp", c.Nginx.HTTPPort):  "nginx.http_port",
+	}
+	if c.Nginx.HTTP3 {
+		used[fmt.Sprintf("%d/udp", c.Nginx.HTTPSPort)] = "nginx.http3"
+	}
+
+	names := map[string]bool{}
+	for _, stream := range c.Nginx.Streams {
+		if !streamNamePattern.MatchString(stream.Name) {
+			return fmt.Errorf("invalid stream name %q", stream.Name)
+		}
+		if names[stream.Name] {
+			return fmt.Errorf("duplicate stream name: %s", stream.Name)
+		}
+		names[stream.Name] = true
+
+		if err := stream.validate(); err != nil {
+			return fmt.Errorf("stream %s: %w", stream.Name, err)
+		}
+
+		key := fmt.Sprintf("%d/%s", stream.Listen, stream.TransportProtocol())
+		if owner, ok := used[key]; ok {
+			return fmt.Errorf("stream %s: port %s is already used by %s", stream.Name, key, owner)
+		}
+		used[key] = "stream " + stream.Name
+	}
+
+	return nil
+}
+
+func (s StreamConfig) validate() error {
+	if s.Listen <= 0 || s.Listen > 65535 {
+		return fmt.Errorf("invalid listen port: %d", s.Listen)
+	}
+
+	if s.Protocol != "" && s.Protocol != "tcp" && s.Protocol != "udp" {
+		return fmt.Errorf("invalid protocol %q (expected tcp or udp)", s.Protocol)
+	}
+
+	if s.TransportProtocol() == "udp" && (s.TLS || len(s.SNIRoutes) > 0) {
+		return fmt.Errorf("tls and sni_routes require protocol tcp")
+	}
+
+	if s.TLS && len(s.SNIRoutes) > 0 {
+		return fmt.Errorf("tls and sni_routes cannot be combined; sni_routes passes TLS through to the backends")
+	}
+
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", s.Port)
+	}
+
+	if s.Backend() == "" {
+		if len(s.SNIRoutes) > 0 {
+			return fmt.Errorf("sni_routes needs port or proxy_pass for server names without a route")
+		}
+		return fmt.Errorf("port or proxy_pass is required")
+	}
+
+	if s.ProxyPass != "" && !validHostPort(s.ProxyPass) {
+		return fmt.Errorf("invalid proxy_pass %q (expected host:port)", s.ProxyPass)
+	}
+
+	for serverName, backend := range s.SNIRoutes {
+		if serverName == "" || strings.ContainsAny(serverName, " ;{}") {
+			return fmt.Errorf("invalid sni_routes server name %q", serverName)
+		}
+		if !validHostPort(backend) {
+			return fmt.Errorf("invalid sni_routes backend %q for %s (expected host:port)", backend, serverName)
+		}
+	}
+
+	for name, value := range map[string]string{"connect_timeout": s.ConnectTimeout, "timeout": s.Timeout} {
+		if value != "" && !durationPattern.MatchString(value) {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+	}
+
+	return nil
+}
+
+func validHostPort(address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" || strings.ContainsAny(host, " ;{}") {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -24,6 +25,7 @@ type CertificateRequest struct {
 	Organization string
 	Unit         string
 	Email        string
+	DNSNames     []string
 }
 
 type KeyPair struct {
@@ -84,6 +86,12 @@ func (g *Generator) GenerateKeyPair(req CertificateRequest) (*KeyPair, error) {
 		template.DNSNames = append(template.DNSNames, "*."+req.Domain)
 	}
 
+	for _, name := range req.DNSNames {
+		if !containsString(template.DNSNames, name) {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
 	if req.Email != "" {
 		template.EmailAddresses = []string{req.Email}
 	}
@@ -173,3 +181,27 @@ func (g *Generator) ValidateCertificate(certPath string) (*CertificateInfo, erro
 
 	return info, nil
 }
+
+func (i *CertificateInfo) CoversHost(host string) bool {
+	for _, name := range i.DNSNames {
+		if strings.EqualFold(name, host) {
+			return true
+		}
+		if strings.HasPrefix(name, "*.") {
+			label, rest, found := strings.Cut(host, ".")
+			if found && label != "" && strings.EqualFold(rest, name[2:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Htpasswd is an Apache-style password file with bcrypt hashes, the format
+// nginx reads through auth_basic_user_file.
+type Htpasswd struct {
+	path    string
+	entries map[string]string
+}
+
+func LoadHtpasswd(path string) (*Htpasswd, error) {
+	h := &Htpasswd{path: path, entries: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read htpasswd file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid htpasswd line: %q", line)
+		}
+		h.entries[user] = hash
+	}
+
+	return h, scanner.Err()
+}
+
+func (h *Htpasswd) SetPassword(user, password string) error {
+	if user == "" || strings.ContainsAny(user, ":\n") {
+		return fmt.Errorf("invalid username: %q", user)
+	}
+	if password == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	h.entries[user] = string(hash)
+	return nil
+}
+
+func (h *Htpasswd) Remove(user string) bool {
+	if _, ok := h.entries[user]; !ok {
+		return false
+	}
+	delete(h.entries, user)
+	return true
+}
+
+func (h *Htpasswd) Has(user string) bool {
+	_, ok := h.entries[user]
+	return ok
+}
+
+func (h *Htpasswd) Users() []string {
+	users := make([]string, 0, len(h.entries))
+	for user := range h.entries {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// Save writes the file world-readable: the nginx workers in the container do
+// not run as the host user that owns the bind-mounted file.
+func (h *Htpasswd) Save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for _, user := range h.Users() {
+		fmt.Fprintf(&buf, "%s:%s\n", user, h.entries[user])
+	}
+
+	if err := os.WriteFile(h.path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write htpasswd file: %w", err)
+	}
+	return nil
+}
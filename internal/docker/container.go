@@ -9,17 +9,23 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
 )
 
 type ContainerConfig struct {
 	Name        string
 	Domain      string
-	HTTPSPort   string
-	HTTPPort    string
+	Ports       []string
 	ProjectDir  string
 	NginxImage  string
 	NetworkName string
+	// ExternalNetwork means NetworkName is owned by another project and must
+	// already exist.
+	ExternalNetwork bool
+	ExtraMounts     []mount.Mount
+	// ExtraHosts are host:ip entries added to the container's /etc/hosts.
+	ExtraHosts []string
 }
 
 type ContainerStatus struct {
@@ -38,37 +44,18 @@ func (c *Client) CreateContainer(config ContainerConfig) (string, error) {
 		return "", fmt.Errorf("container %s already exists", config.Name)
 	}
 
-	portBindings := nat.PortMap{
-		"80/tcp":  []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: config.HTTPPort}},
-		"443/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: config.HTTPSPort}},
+	specs := make([]string, len(config.Ports))
+	for i, port := range config.Ports {
+		specs[i] = "0.0.0.0:" + port
 	}
-
-	mounts := []mount.Mount{
-		{
-			Type:     mount.TypeBind,
-			Source:   fmt.Sprintf("%s/nginx.conf", config.ProjectDir),
-			Target:   "/etc/nginx/nginx.conf",
-			ReadOnly: true,
-		},
-		{
-			Type:     mount.TypeBind,
-			Source:   fmt.Sprintf("%s/ssl", config.ProjectDir),
-			Target:   "/etc/nginx/ssl",
-			ReadOnly: true,
-		},
-		{
-			Type:   mount.TypeBind,
-			Source: fmt.Sprintf("%s/logs", config.ProjectDir),
-			Target: "/var/log/nginx",
-		},
+	exposedPorts, portBindings, err := nat.ParsePortSpecs(specs)
+	if err != nil {
+		return "", fmt.Errorf("invalid port mapping: %w", err)
 	}
 
 	containerConfig := &container.Config{
-		Image: config.NginxImage,
-		ExposedPorts: nat.PortSet{
-			"80/tcp":  {},
-			"443/tcp": {},
-		},
+		Image:        config.NginxImage,
+		ExposedPorts: exposedPorts,
 		Labels: map[string]string{
 			"created-by":       "keynginx",
 			"keynginx.domain":  config.Domain,
@@ -78,17 +65,31 @@ func (c *Client) CreateContainer(config ContainerConfig) (string, error) {
 
 	hostConfig := &container.HostConfig{
 		PortBindings: portBindings,
-		Mounts:       mounts,
+		Mounts:       projectMounts(config),
 		RestartPolicy: container.RestartPolicy{
 			Name: "unless-stopped",
 		},
 	}
 
+	var networkingConfig *network.NetworkingConfig
+	if config.NetworkName != "" {
+		if err := c.EnsureNetwork(config.NetworkName, config.ExternalNetwork); err != nil {
+			return "", err
+		}
+		// Services on the network are reached by their names from proxy_pass.
+		hostConfig.NetworkMode = container.NetworkMode(config.NetworkName)
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				config.NetworkName: {},
+			},
+		}
+	}
+
 	resp, err := c.cli.ContainerCreate(
 		c.ctx,
 		containerConfig,
 		hostConfig,
-		nil,
+		networkingConfig,
 		nil,
 		config.Name,
 	)
@@ -99,6 +100,31 @@ func (c *Client) CreateContainer(config ContainerConfig) (string, error) {
 	return resp.ID, nil
 }
 
+// projectMounts are the project files every nginx container needs, followed
+// by the configured extra mounts.
+func projectMounts(config ContainerConfig) []mount.Mount {
+	mounts := []mount.Mount{
+		{
+			Type:     mount.TypeBind,
+			Source:   fmt.Sprintf("%s/nginx.conf", config.ProjectDir),
+			Target:   "/etc/nginx/nginx.conf",
+			ReadOnly: true,
+		},
+		{
+			Type:     mount.TypeBind,
+			Source:   fmt.Sprintf("%s/ssl", config.ProjectDir),
+			Target:   "/etc/nginx/ssl",
+			ReadOnly: true,
+		},
+		{
+			Type:   mount.TypeBind,
+			Source: fmt.Sprintf("%s/logs", config.ProjectDir),
+			Target: "/var/log/nginx",
+		},
+	}
+	return append(mounts, config.ExtraMounts...)
+}
+
 func (c *Client) StartContainer(containerID string) error {
 	err := c.cli.ContainerStart(c.ctx, containerID, container.StartOptions{})
 	if err != nil {
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+)
+
+func (c *Client) InspectContainer(containerID string) (container.InspectResponse, error) {
+	info, err := c.cli.ContainerInspect(c.ctx, containerID)
+	if err != nil {
+		return container.InspectResponse{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return info, nil
+}
+
+// ContainerDrift lists the mounts and published ports in cfg that differ from
+// the existing container. A reload only replaces nginx.conf and the
+// certificates, so these need 'keynginx up --recreate'.
+func (m *Manager) ContainerDrift(cfg *config.Config) ([]string, error) {
+	containerName := fmt.Sprintf("keynginx-%s", cfg.Project.Domain)
+	existing, err := m.client.GetContainerByName(containerName)
+	if err != nil {
+		return nil, fmt.Errorf("container %s not found", containerName)
+	}
+
+	info, err := m.client.InspectContainer(existing.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted, err := projectContainerConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := mountDrift(projectMounts(wanted), info.Mounts)
+	portChanges, err := portDrift(wanted.Ports, info.HostConfig)
+	if err != nil {
+		return nil, err
+	}
+	return append(changes, portChanges...), nil
+}
+
+func mountDrift(wanted []mount.Mount, actual []container.MountPoint) []string {
+	current := map[string]container.MountPoint{}
+	for _, point := range actual {
+		current[point.Destination] = point
+	}
+
+	var changes []string
+	seen := map[string]bool{}
+	for _, m := range wanted {
+		seen[m.Target] = true
+		point, ok := current[m.Target]
+		switch {
+		case !ok:
+			changes = append(changes, "mount "+m.Target+" added")
+		case m.Type == mount.TypeVolume && point.Name != m.Source,
+			m.Type == mount.TypeBind && point.Source != m.Source,
+			point.RW == m.ReadOnly:
+			changes = append(changes, "mount "+m.Target+" changed")
+		}
+	}
+	for _, point := range actual {
+		if !seen[point.Destination] {
+			changes = append(changes, "mount "+point.Destination+" removed")
+		}
+	}
+	return changes
+}
+
+func portDrift(wanted []string, hostConfig *container.HostConfig) ([]string, error) {
+	specs := make([]string, len(wanted))
+	for i, port := range wanted {
+		specs[i] = "0.0.0.0:" + port
+	}
+	_, bindings, err := nat.ParsePortSpecs(specs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port mapping: %w", err)
+	}
+
+	current := nat.PortMap{}
+	if hostConfig != nil {
+		current = hostConfig.PortBindings
+	}
+
+	var changes []string
+	for port, binding := range bindings {
+		if !samePorts(binding, current[port]) {
+			changes = append(changes, fmt.Sprintf("port %s published on %s", port, hostPorts(binding)))
+		}
+	}
+	for port := range current {
+		if _, ok := bindings[port]; !ok {
+			changes = append(changes, fmt.Sprintf("port %s no longer published", port))
+		}
+	}
+	sort.Strings(changes)
+	return changes, nil
+}
+
+func samePorts(wanted, actual []nat.PortBinding) bool {
+	return hostPorts(wanted) == hostPorts(actual)
+}
+
+func hostPorts(bindings []nat.PortBinding) string {
+	ports := make([]string, len(bindings))
+	for i, binding := range bindings {
+		ports[i] = binding.HostPort
+	}
+	sort.Strings(ports)
+	return strings.Join(ports, ", ")
+}
@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Exec runs a command in a running container and returns its combined
+// output and exit code.
+func (c *Client) Exec(containerID string, cmd []string) (string, int, error) {
+	exec, err := c.cli.ContainerExecCreate(c.ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	attach, err := c.cli.ContainerExecAttach(c.ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer attach.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, attach.Reader); err != nil {
+		return "", 0, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	inspect, err := c.cli.ContainerExecInspect(c.ctx, exec.ID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+
+	return output.String(), inspect.ExitCode, nil
+}
+
+func (c *Client) SignalContainer(containerID, signal string) error {
+	if err := c.cli.ContainerKill(c.ctx, containerID, signal); err != nil {
+		return fmt.Errorf("failed to send %s to container: %w", signal, err)
+	}
+	return nil
+}
@@ -3,11 +3,16 @@ package docker
 import (
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
 	"github.com/sinhaparth5/keynginx/internal/utils"
 )
 
@@ -48,38 +53,210 @@ func (m *Manager) CreateAndStartContainer(cfg *config.Config) (string, error) {
 		return "", err
 	}
 
-	projectDir, err := utils.GetAbsolutePath(cfg.Project.OutputDir)
+	containerConfig, err := projectContainerConfig(cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to get absolute path: %w", err)
+		return "", err
 	}
 
-	logsDir := filepath.Join(projectDir, "logs")
-	if err := utils.EnsureDirectory(logsDir); err != nil {
-		return "", fmt.Errorf("failed to create logs directory: %w", err)
+	containerID, err := m.client.CreateContainer(containerConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.client.StartContainer(containerID); err != nil {
+		return "", fmt.Errorf("container created but failed to start: %w", err)
+	}
+
+	return containerID, nil
+}
+
+// ValidateConfig runs nginx -t against the project's generated files in a
+// throwaway container of the configured image. Backend host names are mapped
+// to 127.0.0.1 for the test, so it passes whether or not the services are
+// running. Warnings of a passing test are returned; a failing test returns a
+// *ValidationError.
+func (m *Manager) ValidateConfig(cfg *config.Config) ([]nginx.Diagnostic, error) {
+	if err := m.validateProjectFiles(cfg); err != nil {
+		return nil, err
+	}
+
+	containerConfig, err := projectContainerConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := readGenerated(containerConfig.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range nginx.BackendHosts(generated) {
+		containerConfig.ExtraHosts = append(containerConfig.ExtraHosts, host+":127.0.0.1")
+	}
+
+	output, passed, err := m.client.TestNginxConfig(containerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnostics := nginx.Diagnose(cfg, generated, output)
+	if !passed {
+		return nil, &ValidationError{Diagnostics: diagnostics, Output: output}
 	}
+	return diagnostics, nil
+}
 
+// Reload applies the project's nginx.conf and certificates to the running
+// container without restarting it, so open connections and WebSocket
+// sessions survive. The configuration is tested with nginx -t inside the
+// container, where backend names resolve as they will after the reload, and
+// nginx is signalled with nginx -s reload, or SIGHUP when exec is not
+// possible. If either step fails and previous is not nil, it is written back
+// to nginx.conf so the file matches what nginx keeps running.
+func (m *Manager) Reload(cfg *config.Config, previous []byte) error {
 	containerName := fmt.Sprintf("keynginx-%s", cfg.Project.Domain)
 
-	containerConfig := ContainerConfig{
-		Name:        containerName,
-		Domain:      cfg.Project.Domain,
-		HTTPSPort:   fmt.Sprintf("%d", cfg.Nginx.HTTPSPort),
-		HTTPPort:    fmt.Sprintf("%d", cfg.Nginx.HTTPPort),
-		ProjectDir:  projectDir,
-		NginxImage:  cfg.Docker.NginxImage,
-		NetworkName: cfg.Docker.NetworkName,
+	container, err := m.client.GetContainerByName(containerName)
+	if err != nil {
+		return fmt.Errorf("container %s not found (start it with 'keynginx up')", containerName)
+	}
+	if container.State != "running" {
+		return fmt.Errorf("container %s is not running (start it with 'keynginx up')", containerName)
 	}
 
-	containerID, err := m.client.CreateContainer(containerConfig)
+	configPath := filepath.Join(cfg.Project.OutputDir, "nginx.conf")
+	err = m.reload(cfg, container.ID)
+	if err != nil && previous != nil {
+		if restoreErr := os.WriteFile(configPath, previous, 0644); restoreErr != nil {
+			return fmt.Errorf("%w (restoring the previous nginx.conf also failed: %v)", err, restoreErr)
+		}
+		return &RollbackError{Err: err}
+	}
+	return err
+}
+
+func (m *Manager) reload(cfg *config.Config, containerID string) error {
+	generated, err := readGenerated(cfg.Project.OutputDir)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	if err := m.client.StartContainer(containerID); err != nil {
-		return "", fmt.Errorf("container created but failed to start: %w", err)
+	output, exitCode, err := m.client.Exec(containerID, []string{"nginx", "-t"})
+	if err != nil {
+		return fmt.Errorf("failed to test configuration: %w", err)
+	}
+	if exitCode != 0 {
+		return &ValidationError{Diagnostics: nginx.Diagnose(cfg, generated, output), Output: output}
 	}
 
-	return containerID, nil
+	output, exitCode, err = m.client.Exec(containerID, []string{"nginx", "-s", "reload"})
+	if err == nil && exitCode == 0 {
+		return nil
+	}
+	if signalErr := m.client.SignalContainer(containerID, "SIGHUP"); signalErr != nil {
+		if err == nil {
+			err = fmt.Errorf("%s", strings.TrimSpace(output))
+		}
+		return fmt.Errorf("failed to reload nginx: %v; %w", err, signalErr)
+	}
+	return nil
+}
+
+// RollbackError is a failed reload after which the previous nginx.conf was
+// restored.
+type RollbackError struct {
+	Err error
+}
+
+func (e *RollbackError) Error() string {
+	return e.Err.Error() + " (restored the previous nginx.conf)"
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// readGenerated parses the nginx.conf in a project directory.
+func readGenerated(projectDir string) (*ast.Config, error) {
+	data, err := os.ReadFile(filepath.Join(projectDir, "nginx.conf"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nginx.conf: %w", err)
+	}
+	generated, err := ast.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nginx.conf: %w", err)
+	}
+	return generated, nil
+}
+
+// ValidationError is a configuration rejected by nginx -t.
+type ValidationError struct {
+	Diagnostics []nginx.Diagnostic
+	Output      string
+}
+
+func (e *ValidationError) Error() string {
+	for _, diagnostic := range e.Diagnostics {
+		if diagnostic.Level == "emerg" {
+			return "nginx rejected the configuration: " + diagnostic.String()
+		}
+	}
+	return "nginx rejected the configuration: " + strings.TrimSpace(e.Output)
+}
+
+// projectContainerConfig describes the nginx container of a project.
+func projectContainerConfig(cfg *config.Config) (ContainerConfig, error) {
+	projectDir, err := utils.GetAbsolutePath(cfg.Project.OutputDir)
+	if err != nil {
+		return ContainerConfig{}, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	logsDir := filepath.Join(projectDir, "logs")
+	if err := utils.EnsureDirectory(logsDir); err != nil {
+		return ContainerConfig{}, fmt.Errorf("failed to create logs directory: %w", err)
+	}
+
+	var extraMounts []mount.Mount
+	for _, m := range cfg.Mounts() {
+		if m.Volume {
+			extraMounts = append(extraMounts, mount.Mount{
+				Type:     mount.TypeVolume,
+				Source:   m.Source,
+				Target:   m.Target,
+				ReadOnly: m.ReadOnly,
+			})
+			continue
+		}
+
+		source := m.Source
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(projectDir, source)
+		}
+		extraMounts = append(extraMounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   source,
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+
+	var ports []string
+	for _, port := range cfg.PublishedPorts() {
+		ports = append(ports, port.String())
+	}
+
+	containerName := fmt.Sprintf("keynginx-%s", cfg.Project.Domain)
+
+	return ContainerConfig{
+		Name:            containerName,
+		Domain:          cfg.Project.Domain,
+		Ports:           ports,
+		ProjectDir:      projectDir,
+		NginxImage:      cfg.Docker.NginxImage,
+		NetworkName:     cfg.Docker.NetworkName,
+		ExternalNetwork: cfg.Docker.ExternalNetwork,
+		ExtraMounts:     extraMounts,
+	}, nil
 }
 
 func (m *Manager) StopAndRemoveContainer(cfg *config.Config) error {
@@ -111,6 +288,8 @@ func (m *Manager) GetProjectStatus(cfg *config.Config) (*ProjectStatus, error) {
 			ProjectName:   cfg.Project.Name,
 			Domain:        cfg.Project.Domain,
 			ContainerName: containerName,
+			HTTPSPort:     cfg.Nginx.HTTPSPort,
+			HTTPPort:      cfg.Nginx.HTTPPort,
 			Status:        "not-found",
 			Message:       "Container not found",
 		}, nil
@@ -129,6 +308,8 @@ func (m *Manager) GetProjectStatus(cfg *config.Config) (*ProjectStatus, error) {
 		Status:        status.State,
 		Message:       status.Status,
 		Ports:         status.Ports,
+		HTTPSPort:     cfg.Nginx.HTTPSPort,
+		HTTPPort:      cfg.Nginx.HTTPPort,
 		Created:       status.Created,
 		Image:         status.Image,
 	}
@@ -185,6 +366,12 @@ func (m *Manager) validateProjectFiles(cfg *config.Config) error {
 		"ssl/certificate.crt": "SSL certificate",
 	}
 
+	for _, site := range cfg.Nginx.Sites {
+		certificate, key := site.CertificateFiles()
+		requiredFiles[filepath.Join("ssl", certificate)] = fmt.Sprintf("SSL certificate for site %s", site.Name)
+		requiredFiles[filepath.Join("ssl", key)] = fmt.Sprintf("SSL private key for site %s", site.Name)
+	}
+
 	for file, description := range requiredFiles {
 		filePath := filepath.Join(projectDir, file)
 		if !utils.FileExists(filePath) {
@@ -192,6 +379,26 @@ func (m *Manager) validateProjectFiles(cfg *config.Config) error {
 		}
 	}
 
+	if cfg.BasicAuthUsed() {
+		htpasswdPath := filepath.Join(projectDir, config.HtpasswdFile)
+		if !utils.FileExists(htpasswdPath) {
+			return fmt.Errorf("basic auth is enabled but %s does not exist\n\nRun 'keynginx auth add-user <name>' to create it", htpasswdPath)
+		}
+	}
+
+	for _, service := range cfg.AllServices() {
+		if !service.IsStatic() {
+			continue
+		}
+		root := service.Static.Root
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(projectDir, root)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("static root for service %s not found: %s", service.Name, root)
+		}
+	}
+
 	return nil
 }
 
@@ -203,6 +410,8 @@ type ProjectStatus struct {
 	Status        string       `json:"status"`
 	Message       string       `json:"message"`
 	Ports         []types.Port `json:"ports"`
+	HTTPSPort     int          `json:"https_port"`
+	HTTPPort      int          `json:"http_port"`
 	Created       time.Time    `json:"created"`
 	Image         string       `json:"image"`
 }
@@ -211,20 +420,24 @@ func (ps *ProjectStatus) IsRunning() bool {
 	return ps.Status == "running"
 }
 
+// GetHTTPSURL and GetHTTPURL look up the published ports by the configured
+// container ports, which nginx listens on since ports became configurable.
 func (ps *ProjectStatus) GetHTTPSURL() string {
-	for _, port := range ps.Ports {
-		if port.PrivatePort == 443 && port.PublicPort != 0 {
-			return fmt.Sprintf("https://localhost:%d", port.PublicPort)
-		}
-	}
-	return fmt.Sprintf("https://%s", ps.Domain)
+	return ps.url("https", ps.HTTPSPort, 443)
 }
 
 func (ps *ProjectStatus) GetHTTPURL() string {
+	return ps.url("http", ps.HTTPPort, 80)
+}
+
+func (ps *ProjectStatus) url(scheme string, containerPort, defaultPort int) string {
 	for _, port := range ps.Ports {
-		if port.PrivatePort == 80 && port.PublicPort != 0 {
-			return fmt.Sprintf("http://localhost:%d", port.PublicPort)
+		if int(port.PrivatePort) == containerPort && port.Type == "tcp" && port.PublicPort != 0 {
+			return fmt.Sprintf("%s://localhost:%d", scheme, port.PublicPort)
 		}
 	}
-	return fmt.Sprintf("http://%s", ps.Domain)
+	if containerPort != 0 && containerPort != defaultPort {
+		return fmt.Sprintf("%s://%s:%d", scheme, ps.Domain, containerPort)
+	}
+	return fmt.Sprintf("%s://%s", scheme, ps.Domain)
 }
@@ -0,0 +1,36 @@
+package docker
+
+import (
+	"fmt"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/network"
+)
+
+// EnsureNetwork makes sure the named network exists. An external network
+// belongs to another project, such as the application's compose stack, and
+// is only checked; otherwise it is created.
+func (c *Client) EnsureNetwork(name string, external bool) error {
+	_, err := c.cli.NetworkInspect(c.ctx, name, network.InspectOptions{})
+	if err == nil {
+		return nil
+	}
+	if !cerrdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to inspect network %s: %w", name, err)
+	}
+
+	if external {
+		return fmt.Errorf("network %s not found (start the project that owns it first)", name)
+	}
+
+	_, err = c.cli.NetworkCreate(c.ctx, name, network.CreateOptions{
+		Driver: "bridge",
+		Labels: map[string]string{
+			"created-by": "keynginx",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create network %s: %w", name, err)
+	}
+	return nil
+}
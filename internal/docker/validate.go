@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// TestNginxConfig runs nginx -t in a throwaway container with the project
+// files mounted and returns its output and whether the test passed. The
+// container publishes no ports and joins no network, so it can run next to
+// the live one.
+func (c *Client) TestNginxConfig(config ContainerConfig) (string, bool, error) {
+	if err := c.ensureImage(config.NginxImage); err != nil {
+		return "", false, err
+	}
+
+	resp, err := c.cli.ContainerCreate(
+		c.ctx,
+		&container.Config{
+			Image: config.NginxImage,
+			Cmd:   []string{"nginx", "-t"},
+		},
+		&container.HostConfig{
+			Mounts:     projectMounts(config),
+			ExtraHosts: config.ExtraHosts,
+		},
+		nil,
+		nil,
+		"",
+	)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create test container: %w", err)
+	}
+	defer c.cli.ContainerRemove(c.ctx, resp.ID, container.RemoveOptions{Force: true})
+
+	waitC, errC := c.cli.ContainerWait(c.ctx, resp.ID, container.WaitConditionNextExit)
+	if err := c.cli.ContainerStart(c.ctx, resp.ID, container.StartOptions{}); err != nil {
+		return "", false, fmt.Errorf("failed to start test container: %w", err)
+	}
+
+	var exitCode int64
+	select {
+	case result := <-waitC:
+		exitCode = result.StatusCode
+	case err := <-errC:
+		return "", false, fmt.Errorf("failed to wait for test container: %w", err)
+	}
+
+	logs, err := c.cli.ContainerLogs(c.ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read test output: %w", err)
+	}
+	defer logs.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, logs); err != nil {
+		return "", false, fmt.Errorf("failed to read test output: %w", err)
+	}
+
+	return output.String(), exitCode == 0, nil
+}
+
+// ensureImage pulls the image unless it is already present.
+func (c *Client) ensureImage(name string) error {
+	_, err := c.cli.ImageInspect(c.ctx, name)
+	if err == nil {
+		return nil
+	}
+	if !cerrdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to inspect image %s: %w", name, err)
+	}
+
+	progress, err := c.cli.ImagePull(c.ctx, name, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", name, err)
+	}
+	defer progress.Close()
+
+	if _, err := io.Copy(io.Discard, progress); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", name, err)
+	}
+	return nil
+}
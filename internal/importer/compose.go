@@ -0,0 +1,362 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+)
+
+var projectNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// nonHTTPPorts are well-known ports of databases, brokers and other
+// services that cannot be proxied as HTTP.
+var nonHTTPPorts = map[int]string{
+	22:    "ssh",
+	25:    "smtp",
+	53:    "dns",
+	1433:  "sqlserver",
+	1521:  "oracle",
+	1883:  "mqtt",
+	2181:  "zookeeper",
+	3306:  "mysql",
+	4222:  "nats",
+	5432:  "postgres",
+	5672:  "amqp",
+	6379:  "redis",
+	8883:  "mqtt",
+	9042:  "cassandra",
+	9092:  "kafka",
+	11211: "memcached",
+	27017: "mongodb",
+}
+
+// proxyImages are reverse proxies that keynginx takes the place of.
+var proxyImages = map[string]bool{
+	"nginx":   true,
+	"traefik": true,
+	"caddy":   true,
+	"haproxy": true,
+	"envoy":   true,
+}
+
+// Words in a service name that suggest where it is routed.
+var (
+	rootServiceWords = map[string]bool{"frontend": true, "web": true, "ui": true, "app": true, "client": true, "www": true, "site": true, "webapp": true}
+	apiServiceWords  = map[string]bool{"api": true, "backend": true, "server": true}
+)
+
+type composeFile struct {
+	Name     string                    `yaml:"name"`
+	Services yaml.Node                 `yaml:"services"`
+	Networks map[string]composeNetwork `yaml:"networks"`
+}
+
+type composeNetwork struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+}
+
+type composeService struct {
+	Image       string      `yaml:"image"`
+	Ports       []yaml.Node `yaml:"ports"`
+	Expose      []yaml.Node `yaml:"expose"`
+	Networks    yaml.Node   `yaml:"networks"`
+	NetworkMode string      `yaml:"network_mode"`
+}
+
+type composePortSpec struct {
+	Target   int    `yaml:"target"`
+	Protocol string `yaml:"protocol"`
+}
+
+// discoveredService is a compose service with an HTTP port.
+type discoveredService struct {
+	name     string
+	port     int
+	line     int
+	networks []string
+}
+
+// ImportCompose adds the HTTP services of a docker-compose file to cfg. Each
+// service with a published or exposed HTTP port becomes a proxied service
+// with a suggested path, and the nginx container joins the network the
+// services share.
+func ImportCompose(cfg *config.Config, file string) (*Result, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var compose composeFile
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	if compose.Services.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s has no services", file)
+	}
+
+	result := &Result{Config: cfg}
+	report := func(line int, name, reason string) {
+		result.Issues = append(result.Issues, Issue{File: file, Line: line, Directive: "services." + name, Reason: reason})
+	}
+
+	var discovered []discoveredService
+	for i := 0; i+1 < len(compose.Services.Content); i += 2 {
+		key, node := compose.Services.Content[i], compose.Services.Content[i+1]
+
+		var service composeService
+		if err := node.Decode(&service); err != nil {
+			return nil, fmt.Errorf("%s: service %s: %w", file, key.Value, err)
+		}
+
+		published, exposed := service.ports()
+		if len(published)+len(exposed) == 0 {
+			continue
+		}
+		if proxyImages[imageName(service.Image)] && len(published) > 0 {
+			report(key.Line, key.Value, "reverse proxies are replaced by keynginx")
+			continue
+		}
+		if service.NetworkMode != "" {
+			report(key.Line, key.Value, "network_mode "+service.NetworkMode+" cannot be shared with nginx")
+			continue
+		}
+
+		port, skipped := httpPort(append(published, exposed...))
+		if port == 0 {
+			report(key.Line, key.Value, "no HTTP port; "+skipped+" can be exposed with nginx.streams")
+			continue
+		}
+
+		discovered = append(discovered, discoveredService{
+			name:     key.Value,
+			port:     port,
+			line:     key.Line,
+			networks: service.networkNames(),
+		})
+	}
+
+	addComposeServices(cfg, discovered, report)
+
+	if network, ok := sharedNetwork(discovered, report); ok {
+		cfg.Docker.NetworkName = compose.networkName(network, projectName(file, compose.Name))
+		cfg.Docker.ExternalNetwork = true
+		result.note("start the compose project before keynginx so the %s network exists", cfg.Docker.NetworkName)
+	}
+
+	return result, nil
+}
+
+func addComposeServices(cfg *config.Config, discovered []discoveredService, report func(int, string, string)) {
+	usedPaths := map[string]bool{}
+	for _, service := range cfg.Nginx.Services {
+		usedPaths[service.LocationPath()] = true
+	}
+
+	for _, service := range discovered {
+		if hasService(cfg, service.name) {
+			report(service.line, service.name, "a service with this name is already configured")
+			continue
+		}
+
+		path := suggestPath(service.name, usedPaths, len(discovered) == 1)
+		usedPaths[path] = true
+
+		cfg.AddService(service.name, service.port, path)
+		added := &cfg.Nginx.Services[len(cfg.Nginx.Services)-1]
+		switch service.port {
+		case 443:
+			added.Protocol = "https"
+		case 50051:
+			added.Protocol = "grpc"
+		}
+	}
+}
+
+func hasService(cfg *config.Config, name string) bool {
+	for _, service := range cfg.Nginx.Services {
+		if service.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestPath routes the first frontend-like service at / and the first
+// API-like service at /api; everything else gets /<name>.
+func suggestPath(name string, used map[string]bool, only bool) string {
+	if only && !used["/"] {
+		return "/"
+	}
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+		if rootServiceWords[word] && !used["/"] {
+			return "/"
+		}
+		if apiServiceWords[word] && !used["/api"] {
+			return "/api"
+		}
+	}
+	return "/" + name
+}
+
+// sharedNetwork picks the network most HTTP services are on. Services
+// without networks are on the project's default network.
+func sharedNetwork(services []discoveredService, report func(int, string, string)) (string, bool) {
+	if len(services) == 0 {
+		return "", false
+	}
+
+	counts := map[string]int{}
+	var order []string
+	for _, service := range services {
+		for _, network := range service.networks {
+			if counts[network] == 0 {
+				order = append(order, network)
+			}
+			counts[network]++
+		}
+	}
+
+	best := order[0]
+	for _, network := range order {
+		if counts[network] > counts[best] {
+			best = network
+		}
+	}
+
+	for _, service := range services {
+		if !contains(service.networks, best) {
+			report(service.line, service.name, "not on the "+best+" network that nginx joins")
+		}
+	}
+	return best, true
+}
+
+// ports returns the container ports of a service, published first.
+func (s composeService) ports() ([]int, []int) {
+	var published, exposed []int
+	for _, node := range s.Ports {
+		if port, ok := composePort(node); ok {
+			published = append(published, port)
+		}
+	}
+	for _, node := range s.Expose {
+		if port, ok := composePort(node); ok {
+			exposed = append(exposed, port)
+		}
+	}
+	return published, exposed
+}
+
+// composePort reads the container port of a ports or expose entry, in the
+// short ("8080:80", "127.0.0.1:8080:80/tcp", "3000") or long syntax. UDP
+// ports are skipped, and ranges yield their first port.
+func composePort(node yaml.Node) (int, bool) {
+	spec := node.Value
+	if node.Kind == yaml.MappingNode {
+		var long composePortSpec
+		if err := node.Decode(&long); err != nil {
+			return 0, false
+		}
+		spec = strconv.Itoa(long.Target)
+		if long.Protocol != "" {
+			spec += "/" + long.Protocol
+		}
+	}
+
+	spec, protocol, _ := strings.Cut(spec, "/")
+	if protocol != "" && protocol != "tcp" {
+		return 0, false
+	}
+	if i := strings.LastIndexByte(spec, ':'); i >= 0 {
+		spec = spec[i+1:]
+	}
+	spec, _, _ = strings.Cut(spec, "-")
+
+	port, err := strconv.Atoi(spec)
+	return port, err == nil && port > 0 && port <= 65535
+}
+
+// httpPort returns the first port that is not a well-known non-HTTP port,
+// or the names of the skipped ones.
+func httpPort(ports []int) (int, string) {
+	var skipped []string
+	for _, port := range ports {
+		name, ok := nonHTTPPorts[port]
+		if !ok {
+			return port, ""
+		}
+		skipped = append(skipped, fmt.Sprintf("%s (%d)", name, port))
+	}
+	return 0, strings.Join(skipped, ", ")
+}
+
+func (s composeService) networkNames() []string {
+	switch s.Networks.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		for _, node := range s.Networks.Content {
+			names = append(names, node.Value)
+		}
+		return names
+	case yaml.MappingNode:
+		var names []string
+		for i := 0; i < len(s.Networks.Content); i += 2 {
+			names = append(names, s.Networks.Content[i].Value)
+		}
+		return names
+	}
+	return []string{"default"}
+}
+
+// networkName is the name Docker knows a compose network by: its explicit
+// name, the key for external networks, or <project>_<key>.
+func (c composeFile) networkName(key, project string) string {
+	network := c.Networks[key]
+	switch {
+	case network.Name != "":
+		return network.Name
+	case network.External:
+		return key
+	}
+	return project + "_" + key
+}
+
+// projectName follows docker compose: COMPOSE_PROJECT_NAME, the top-level
+// name, or the directory of the compose file, normalised.
+func projectName(file, name string) string {
+	if env := os.Getenv("COMPOSE_PROJECT_NAME"); env != "" {
+		name = env
+	}
+	if name == "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			name = filepath.Base(filepath.Dir(abs))
+		}
+	}
+	return projectNameChars.ReplaceAllString(strings.ToLower(name), "")
+}
+
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	image = path.Base(image)
+	image, _, _ = strings.Cut(image, ":")
+	return image
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
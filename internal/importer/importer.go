@@ -0,0 +1,40 @@
+// Package importer turns existing setups, such as hand-written nginx
+// configurations, into keynginx project configuration.
+package importer
+
+import (
+	"fmt"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+)
+
+// Issue is a directive that has no equivalent in keynginx.yaml and was left
+// out of the imported configuration.
+type Issue struct {
+	File      string
+	Line      int
+	Directive string
+	Reason    string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", i.File, i.Line, i.Directive, i.Reason)
+}
+
+// Result is an imported configuration. Notes are manual follow-up steps,
+// such as copying certificates into the project.
+type Result struct {
+	Config *config.Config
+	Issues []Issue
+	Notes  []string
+}
+
+func (r *Result) note(format string, args ...interface{}) {
+	note := fmt.Sprintf(format, args...)
+	for _, existing := range r.Notes {
+		if existing == note {
+			return
+		}
+	}
+	r.Notes = append(r.Notes, note)
+}
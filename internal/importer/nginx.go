@@ -0,0 +1,1147 @@
+package importer
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+var (
+	hostNamePattern   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+	nameWordPattern   = regexp.MustCompile(`[A-Za-z0-9_-]+`)
+	invalidNameChars  = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	invalidZoneChars  = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+	headerVariableKey = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// builtinDirectives are written by keynginx for every configuration with
+// the values below. Imported copies are dropped; different values are
+// reported because keynginx.yaml cannot change them.
+var builtinDirectives = map[string]string{
+	"default_type":              "application/octet-stream",
+	"sendfile":                  "on",
+	"tcp_nopush":                "on",
+	"tcp_nodelay":               "on",
+	"keepalive_timeout":         "65",
+	"types_hash_max_size":       "2048",
+	"server_tokens":             "off",
+	"gzip":                      "on",
+	"gzip_vary":                 "on",
+	"gzip_comp_level":           "6",
+	"gzip_min_length":           "1024",
+	"gzip_proxied":              "any",
+	"ssl_prefer_server_ciphers": "off",
+	"ssl_session_cache":         "shared:SSL:10m",
+	"ssl_session_timeout":       "10m",
+	"worker_connections":        "1024",
+	"multi_accept":              "on",
+	"use":                       "epoll",
+	"http2":                     "on",
+}
+
+// replacedDirectives are always replaced by keynginx's own settings, such as
+// its log format and TLS protocol list, without a report.
+var replacedDirectives = map[string]bool{
+	"user":             true,
+	"worker_processes": true,
+	"pid":              true,
+	"log_format":       true,
+	"access_log":       true,
+	"error_log":        true,
+	"gzip_types":       true,
+	"ssl_protocols":    true,
+	"ssl_ciphers":      true,
+}
+
+// proxyHeaders are set by every generated proxy location.
+var proxyHeaders = map[string]string{
+	"host":              "$host",
+	"x-real-ip":         "$remote_addr",
+	"x-forwarded-for":   "$proxy_add_x_forwarded_for",
+	"x-forwarded-proto": "$scheme",
+	"x-forwarded-host":  "$server_name",
+}
+
+// securityHeaders belong to the security profiles, so imported values go to
+// security.custom_headers where they override the profile.
+var securityHeaders = map[string]bool{
+	"strict-transport-security":         true,
+	"content-security-policy":           true,
+	"x-frame-options":                   true,
+	"x-xss-protection":                  true,
+	"x-content-type-options":            true,
+	"referrer-policy":                   true,
+	"x-download-options":                true,
+	"x-permitted-cross-domain-policies": true,
+}
+
+var builtinLocations = map[string]bool{
+	"/health":                   true,
+	"/.well-known/security.txt": true,
+}
+
+type nginxImporter struct {
+	result      *Result
+	cfg         *config.Config
+	upstreams   map[string]bool
+	zones       map[string]string
+	staticNames map[string]bool
+	streamNames map[string]bool
+	mainServer  bool
+	httpPort    int
+}
+
+// serverScope is where the settings of a server block end up: the main
+// server or one of the additional sites.
+type serverScope struct {
+	services  *[]config.ServiceConfig
+	redirects *[]config.RedirectRule
+	rewrites  *[]config.RewriteRule
+	header    func(name, value string)
+}
+
+// ImportNginx reads an nginx configuration, including the files it includes,
+// and maps it onto a default keynginx configuration. The first HTTPS server
+// becomes the main server and later ones become sites; locations become
+// services, redirects or static services.
+func ImportNginx(file string) (*Result, error) {
+	// ParseFile errors already name the file and line.
+	parsed, err := ast.ParseFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.NewDefaultConfig()
+	im := &nginxImporter{
+		result:      &Result{Config: cfg},
+		cfg:         cfg,
+		upstreams:   map[string]bool{},
+		zones:       map[string]string{},
+		staticNames: map[string]bool{},
+		streamNames: map[string]bool{},
+	}
+
+	im.importMain(parsed.Directives)
+	im.finish()
+
+	return im.result, nil
+}
+
+func (im *nginxImporter) importMain(nodes []*ast.Directive) {
+	// A conf.d fragment has server blocks at the top level.
+	if len(filter(nodes, "http")) == 0 && len(filter(nodes, "events")) == 0 {
+		im.importHTTP(nodes)
+		return
+	}
+
+	for _, d := range directives(nodes) {
+		switch d.Name {
+		case "http":
+			im.importHTTP(d.Block)
+		case "stream":
+			im.importStream(d.Block)
+		case "events":
+			for _, child := range directives(d.Block) {
+				im.builtinOrReport(child)
+			}
+		default:
+			im.builtinOrReport(d)
+		}
+	}
+}
+
+func (im *nginxImporter) importHTTP(nodes []*ast.Directive) {
+	// Zones and upstreams first: servers may refer to them from any position.
+	for _, d := range directives(nodes) {
+		switch d.Name {
+		case "limit_req_zone", "limit_conn_zone":
+			im.importZone(d)
+		case "upstream":
+			im.importUpstream(d)
+		}
+	}
+
+	var (
+		defaults config.ServiceConfig
+		headers  = map[string]string{}
+		servers  []*ast.Directive
+	)
+	for _, d := range directives(nodes) {
+		switch d.Name {
+		case "limit_req_zone", "limit_conn_zone", "upstream":
+		case "server":
+			servers = append(servers, d)
+		case "add_header":
+			im.addHeader(headers, d)
+		case "limit_req_status", "limit_conn_status":
+			status, err := strconv.Atoi(d.Arg(0))
+			if err != nil {
+				im.report(d, "invalid status")
+				continue
+			}
+			im.cfg.Security.RateLimit.Status = status
+		case "set_real_ip_from":
+			im.cfg.Security.TrustedProxies = append(im.cfg.Security.TrustedProxies, d.Arg(0))
+		case "real_ip_header":
+			im.cfg.Security.RealIPHeader = d.Arg(0)
+		case "map":
+			if d.Arg(0) != "$http_upgrade" {
+				im.report(d, "no keynginx setting")
+			}
+		case "client_max_body_size":
+			// keynginx sets 16M for the whole http block.
+			if !strings.EqualFold(d.Arg(0), "16m") {
+				defaults.Proxy.MaxBodySize = d.Arg(0)
+			}
+		default:
+			if !im.applyService(&defaults, d) {
+				im.builtinOrReport(d)
+			}
+		}
+	}
+
+	for _, server := range servers {
+		im.importServer(server, defaults, headers)
+	}
+}
+
+func (im *nginxImporter) importServer(server *ast.Directive, defaults config.ServiceConfig, httpHeaders map[string]string) {
+	httpsPort, httpPort := 0, 0
+	for _, d := range server.Children("listen") {
+		port, ok := listenPort(d.Arg(0))
+		if !ok {
+			im.report(d, "only TCP ports can be imported")
+			continue
+		}
+		switch {
+		case hasArg(d, "quic"):
+			im.cfg.Nginx.HTTP3 = true
+		case hasArg(d, "ssl"):
+			httpsPort = port
+		default:
+			httpPort = port
+		}
+	}
+	if httpsPort == 0 && httpPort == 0 {
+		httpPort = 80
+	}
+	if httpsPort == 0 && server.Child("ssl_certificate") != nil {
+		// Older configurations enable TLS with "ssl on" instead of listen.
+		httpsPort, httpPort = httpPort, 0
+	}
+
+	var names []string
+	for _, d := range server.Children("server_name") {
+		for _, name := range d.Args {
+			if name != "" && name != "_" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if httpsPort == 0 && redirectsToHTTPS(server) {
+		// keynginx generates the HTTP to HTTPS redirect for every server.
+		if im.httpPort == 0 {
+			im.httpPort = httpPort
+		}
+		return
+	}
+	if httpPort != 0 && im.httpPort == 0 {
+		im.httpPort = httpPort
+	}
+
+	scope, ok := im.serverScope(server, names, httpsPort)
+	if !ok {
+		return
+	}
+
+	service := copyService(defaults)
+	headers := map[string]string{}
+	for name, value := range httpHeaders {
+		headers[name] = value
+	}
+
+	var locations []*ast.Directive
+	for _, d := range directives(server.Block) {
+		switch d.Name {
+		case "listen", "server_name", "ssl_certificate", "ssl_certificate_key":
+		case "location":
+			locations = append(locations, d)
+		case "add_header":
+			im.addHeader(headers, d)
+		case "rewrite":
+			im.importRewrite(d, scope)
+		case "http3":
+			im.cfg.Nginx.HTTP3 = d.Arg(0) == "on"
+		case "ssl":
+			// "ssl on" was handled with the listen ports.
+		default:
+			if !im.applyService(&service, d) {
+				im.builtinOrReport(d)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(headers) {
+		scope.header(name, headers[name])
+	}
+
+	for _, location := range locations {
+		im.importLocation(location, service, scope)
+	}
+}
+
+// serverScope makes the first server the main server and every later one a
+// site, and records where their certificates must be copied.
+func (im *nginxImporter) serverScope(server *ast.Directive, names []string, httpsPort int) (*serverScope, bool) {
+	nginx := &im.cfg.Nginx
+	certificate, key := server.Child("ssl_certificate"), server.Child("ssl_certificate_key")
+
+	if !im.mainServer {
+		im.mainServer = true
+		if len(names) > 0 {
+			im.cfg.Project.Domain = names[0]
+			nginx.ServerName = strings.Join(names, " ")
+		}
+		if httpsPort != 0 {
+			nginx.HTTPSPort = httpsPort
+		} else if listen := server.Child("listen"); listen != nil {
+			im.report(listen, "plain HTTP server imported as HTTPS; keynginx redirects HTTP to HTTPS")
+		}
+		if certificate != nil && key != nil {
+			im.result.note("copy %s to ssl/certificate.crt and %s to ssl/private.key, or keep the generated self-signed pair", certificate.Arg(0), key.Arg(0))
+		}
+
+		return &serverScope{
+			services:  &nginx.Services,
+			redirects: &nginx.Redirects,
+			rewrites:  &nginx.Rewrites,
+			header:    im.mainHeader,
+		}, true
+	}
+
+	if len(names) == 0 {
+		im.report(server, "additional servers need a server_name to become a site")
+		return nil, false
+	}
+	if httpsPort != 0 && httpsPort != nginx.HTTPSPort {
+		if listen := server.Child("listen"); listen != nil {
+			im.report(listen, "sites share the main server's ports")
+		}
+	}
+
+	site := config.SiteConfig{
+		Name:        im.siteName(names[0]),
+		ServerNames: names,
+	}
+	if certificate != nil && key != nil {
+		site.Certificate = site.Name + "/" + path.Base(certificate.Arg(0))
+		site.CertificateKey = site.Name + "/" + path.Base(key.Arg(0))
+		im.result.note("copy %s to ssl/%s and %s to ssl/%s", certificate.Arg(0), site.Certificate, key.Arg(0), site.CertificateKey)
+	}
+	nginx.Sites = append(nginx.Sites, site)
+
+	// The server is imported completely before the next site is appended.
+	imported := &nginx.Sites[len(nginx.Sites)-1]
+	return &serverScope{
+		services:  &imported.Services,
+		redirects: &imported.Redirects,
+		rewrites:  &imported.Rewrites,
+		header: func(name, value string) {
+			if imported.CustomHeaders == nil {
+				imported.CustomHeaders = map[string]string{}
+			}
+			imported.CustomHeaders[name] = value
+		},
+	}, true
+}
+
+func (im *nginxImporter) siteName(host string) string {
+	base := strings.Trim(invalidNameChars.ReplaceAllString(strings.TrimPrefix(host, "*."), "-"), "-")
+	if base == "" {
+		base = "site"
+	}
+	name := base
+	for i := 2; ; i++ {
+		taken := false
+		for _, site := range im.cfg.Nginx.Sites {
+			taken = taken || site.Name == name
+		}
+		if !taken {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (im *nginxImporter) mainHeader(name, value string) {
+	if securityHeaders[strings.ToLower(name)] {
+		im.cfg.Security.CustomHeaders[name] = value
+		return
+	}
+	im.cfg.Nginx.CustomHeaders[name] = value
+}
+
+func (im *nginxImporter) addHeader(headers map[string]string, d *ast.Directive) {
+	if len(d.Args) < 2 {
+		im.report(d, "invalid add_header")
+		return
+	}
+	// Alt-Svc is generated when HTTP/3 is enabled.
+	if strings.EqualFold(d.Args[0], "Alt-Svc") {
+		return
+	}
+	headers[d.Args[0]] = d.Args[1]
+}
+
+func (im *nginxImporter) importLocation(location *ast.Directive, defaults config.ServiceConfig, scope *serverScope) {
+	locationPath := strings.Join(location.Args, " ")
+	if builtinLocations[strings.TrimPrefix(locationPath, "= ")] {
+		im.report(location, "keynginx serves this location itself")
+		return
+	}
+
+	service := copyService(defaults)
+	service.Path = locationPath
+
+	var pass, ret, alias, tryFiles *ast.Directive
+	var headers []*ast.Directive
+	for _, d := range directives(location.Block) {
+		switch d.Name {
+		case "proxy_pass", "grpc_pass":
+			pass = d
+		case "return":
+			ret = d
+		case "alias":
+			alias = d
+		case "try_files":
+			tryFiles = d
+		case "add_header":
+			headers = append(headers, d)
+		case "location":
+			im.report(d, "nested locations cannot be imported")
+		default:
+			if !im.applyService(&service, d) {
+				im.unsupported(d)
+			}
+		}
+	}
+
+	switch {
+	case pass != nil:
+		if !im.proxyTarget(&service, pass) {
+			im.report(pass, "only http, https and grpc targets can be imported")
+			return
+		}
+		service.Static = config.StaticConfig{}
+		for _, d := range headers {
+			if len(d.Args) < 2 {
+				im.report(d, "invalid add_header")
+				continue
+			}
+			if service.Proxy.AddHeaders == nil {
+				service.Proxy.AddHeaders = map[string]string{}
+			}
+			service.Proxy.AddHeaders[d.Args[0]] = d.Args[1]
+		}
+		*scope.services = append(*scope.services, service)
+
+	case ret != nil:
+		im.importRedirect(location, ret, scope)
+
+	case alias != nil || tryFiles != nil || service.Static.Root != "":
+		im.importStatic(location, service, alias, tryFiles, headers, scope)
+
+	default:
+		im.report(location, "no proxy_pass, return or root to import")
+	}
+}
+
+// proxyTarget maps proxy_pass or grpc_pass onto the service. Targets are
+// kept as written, so a URI part keeps nginx's prefix replacement.
+func (im *nginxImporter) proxyTarget(service *config.ServiceConfig, d *ast.Directive) bool {
+	target := d.Arg(0)
+	scheme, address, ok := strings.Cut(target, "://")
+	if !ok {
+		if d.Name != "grpc_pass" {
+			return false
+		}
+		scheme, address = "grpc", target
+	}
+
+	switch scheme {
+	case "http":
+	case "https", "grpc", "grpcs":
+		service.Protocol = scheme
+	default:
+		return false
+	}
+
+	host, uri := address, ""
+	if i := strings.IndexByte(address, '/'); i >= 0 {
+		host, uri = address[:i], address[i:]
+	}
+
+	if im.upstreams[host] {
+		service.Name = host
+		if uri == "" {
+			service.Upstream = host
+		} else {
+			service.ProxyPass = scheme + "://" + address
+		}
+		return true
+	}
+
+	hostname, port := host, 80
+	if scheme == "https" || scheme == "grpcs" {
+		port = 443
+	}
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+		port, _ = strconv.Atoi(p)
+	}
+
+	service.Name = hostname
+	service.Port = port
+	service.ProxyPass = scheme + "://" + address
+	if !hostNamePattern.MatchString(hostname) || isLoopback(hostname) {
+		service.Name = nameFromPath(service.Path, "app")
+	}
+	if isLoopback(hostname) {
+		im.result.note("service %s proxies to %s, which is the nginx container itself once imported; use a compose service name or host.docker.internal", service.Name, target)
+	}
+	return true
+}
+
+func (im *nginxImporter) importRedirect(location, ret *ast.Directive, scope *serverScope) {
+	code, err := strconv.Atoi(ret.Arg(0))
+	if err != nil || len(ret.Args) != 2 || code != 301 && code != 302 && code != 307 && code != 308 {
+		im.report(location, "only redirecting return locations can be imported")
+		return
+	}
+
+	// return never appends the query string, unlike keynginx redirects.
+	keepQuery := false
+	redirect := config.RedirectRule{To: ret.Arg(1), PreserveQuery: &keepQuery}
+	if code != 301 {
+		redirect.Code = code
+	}
+
+	modifier, locationPath := splitLocation(location)
+	switch modifier {
+	case "=":
+		redirect.From = locationPath
+	case "", "^~":
+		// A prefix location redirects every path below it to one target.
+		redirect.From = "^" + regexp.QuoteMeta(locationPath)
+		redirect.Match = "regex"
+	case "~":
+		redirect.From = locationPath
+		redirect.Match = "regex"
+	case "~*":
+		redirect.From = "(?i)" + locationPath
+		redirect.Match = "regex"
+	default:
+		im.report(location, "unsupported location modifier")
+		return
+	}
+
+	*scope.redirects = append(*scope.redirects, redirect)
+}
+
+func (im *nginxImporter) importStatic(location *ast.Directive, service config.ServiceConfig, alias, tryFiles *ast.Directive, headers []*ast.Directive, scope *serverScope) {
+	if modifier, _ := splitLocation(location); modifier != "" {
+		im.report(location, "static locations need a plain path prefix")
+		return
+	}
+
+	root := path.Join(service.Static.Root, service.Path)
+	if alias != nil {
+		root = alias.Arg(0)
+	}
+	if root == "" || root == "." {
+		im.report(location, "static locations need root or alias")
+		return
+	}
+
+	service.Type = "static"
+	if tryFiles != nil {
+		fallback := tryFiles.Arg(len(tryFiles.Args) - 1)
+		switch {
+		case strings.HasSuffix(fallback, "/"+service.IndexFile()):
+			service.Type = "spa"
+		case fallback != "=404":
+			im.report(tryFiles, "only =404 and index fallbacks can be imported")
+		}
+	}
+
+	for _, d := range headers {
+		im.report(d, "static services only support Cache-Control per file type (static.cache_control)")
+	}
+
+	service.Name = im.staticName(nameFromPath(service.Path, "site"))
+	service.Static.Root = root
+	service.Proxy = config.ProxyOptions{}
+	im.result.note("service %s serves %s from the old server; copy the files next to keynginx.yaml or point static.root at them", service.Name, root)
+
+	*scope.services = append(*scope.services, service)
+}
+
+func (im *nginxImporter) staticName(base string) string {
+	name := base
+	for i := 2; im.staticNames[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	im.staticNames[name] = true
+	return name
+}
+
+func (im *nginxImporter) importRewrite(d *ast.Directive, scope *serverScope) {
+	if len(d.Args) < 2 || len(d.Args) > 3 {
+		im.report(d, "invalid rewrite")
+		return
+	}
+	from, to, flag := d.Arg(0), d.Arg(1), d.Arg(2)
+
+	switch {
+	case flag == "permanent" || flag == "redirect" || flag == "" && isURL(to):
+		redirect := config.RedirectRule{From: from, To: to, Match: "regex"}
+		if flag != "permanent" {
+			redirect.Code = 302
+		}
+		// A trailing "?" stops rewrite from appending the query string.
+		if strings.HasSuffix(to, "?") {
+			keepQuery := false
+			redirect.To = strings.TrimSuffix(to, "?")
+			redirect.PreserveQuery = &keepQuery
+		}
+		*scope.redirects = append(*scope.redirects, redirect)
+
+	case flag == "" || flag == "last" || flag == "break":
+		*scope.rewrites = append(*scope.rewrites, config.RewriteRule{From: from, To: to, Flag: flag})
+
+	default:
+		im.report(d, "unsupported rewrite flag")
+	}
+}
+
+// applyService maps the directives shared by proxied and static locations.
+// Server and http level values are applied to a template service that every
+// location starts from, like nginx inheritance.
+func (im *nginxImporter) applyService(service *config.ServiceConfig, d *ast.Directive) bool {
+	proxy := &service.Proxy
+	value := d.Arg(0)
+
+	switch d.Name {
+	case "proxy_set_header":
+		if len(d.Args) != 2 {
+			return false
+		}
+		name := strings.ToLower(d.Args[0])
+		switch {
+		case name == "upgrade" || name == "connection" && strings.Contains(strings.ToLower(d.Args[1]), "upgrade"):
+			service.WebSocket = true
+		case name == "connection" && d.Args[1] == "":
+			// Sent automatically for keepalive upstreams.
+		case proxyHeaders[name] != "":
+			if proxyHeaders[name] != d.Args[1] {
+				im.report(d, fmt.Sprintf("keynginx always sends %s %s", d.Args[0], proxyHeaders[name]))
+			}
+		default:
+			if proxy.SetHeaders == nil {
+				proxy.SetHeaders = map[string]string{}
+			}
+			proxy.SetHeaders[d.Args[0]] = d.Args[1]
+		}
+	case "proxy_hide_header":
+		if !strings.EqualFold(value, "X-Powered-By") && !strings.EqualFold(value, "Server") {
+			proxy.HideHeaders = append(proxy.HideHeaders, value)
+		}
+	case "proxy_connect_timeout":
+		proxy.ConnectTimeout = value
+	case "proxy_read_timeout":
+		proxy.ReadTimeout = value
+	case "proxy_send_timeout":
+		proxy.SendTimeout = value
+	case "proxy_buffering":
+		proxy.Buffering = onOff(value)
+	case "proxy_request_buffering":
+		proxy.RequestBuffering = onOff(value)
+	case "client_max_body_size":
+		proxy.MaxBodySize = value
+	case "proxy_http_version":
+		proxy.HTTPVersion = value
+	case "proxy_next_upstream":
+		proxy.NextUpstream = append([]string(nil), d.Args...)
+	case "proxy_next_upstream_tries":
+		proxy.NextUpstreamTries, _ = strconv.Atoi(value)
+	case "proxy_next_upstream_timeout":
+		proxy.NextUpstreamTimeout = value
+
+	case "allow":
+		if value != "all" {
+			service.Access.Allow = append(service.Access.Allow, value)
+		}
+	case "deny":
+		// "deny all" after allow rules is implied by access.allow.
+		if value != "all" {
+			service.Access.Deny = append(service.Access.Deny, value)
+		}
+
+	case "auth_basic":
+		enabled := value != "off"
+		service.BasicAuth.Enabled = &enabled
+		if enabled {
+			service.BasicAuth.Realm = value
+		}
+	case "auth_basic_user_file":
+		im.result.note("add the users from %s with 'keynginx auth add-user' (bcrypt hashes can be copied into %s)", value, config.HtpasswdFile)
+
+	case "limit_req", "limit_conn":
+		im.applyRateLimit(service, d)
+
+	case "root":
+		service.Static.Root = value
+	case "index":
+		if value != "index.html" {
+			service.Static.Index = value
+		}
+	case "autoindex":
+		service.Static.Autoindex = value == "on"
+	case "gzip_static":
+		if value == "on" {
+			service.Static.Precompressed = append(service.Static.Precompressed, "gzip")
+		}
+	case "brotli_static":
+		if value == "on" {
+			service.Static.Precompressed = append(service.Static.Precompressed, "br")
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (im *nginxImporter) importZone(d *ast.Directive) {
+	var zone, size, rate string
+	for _, arg := range d.Args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "zone="):
+			zone, size, _ = strings.Cut(strings.TrimPrefix(arg, "zone="), ":")
+		case strings.HasPrefix(arg, "rate="):
+			rate = strings.TrimPrefix(arg, "rate=")
+		default:
+			im.report(d, "unsupported parameter "+arg)
+		}
+	}
+	if len(d.Args) == 0 || zone == "" {
+		im.report(d, "missing zone")
+		return
+	}
+
+	name := strings.Trim(invalidZoneChars.ReplaceAllString(zone, "_"), "_")
+	if name == "" {
+		name = "zone"
+	}
+	for i := 2; im.cfg.Security.RateLimit.Policy(name) != nil; i++ {
+		name = fmt.Sprintf("%s_%d", strings.TrimRight(name, "_0123456789"), i)
+	}
+
+	policy := config.RateLimitPolicy{Name: name, Key: policyKey(d.Arg(0)), Rate: rate}
+	if size != "10m" {
+		policy.Size = size
+	}
+	im.cfg.Security.RateLimit.Policies = append(im.cfg.Security.RateLimit.Policies, policy)
+	im.zones[zone] = name
+}
+
+// applyRateLimit turns limit_req and limit_conn into a policy reference.
+// Burst and connection counts belong to the policy in keynginx, so the first
+// value seen is kept and conflicting ones are reported.
+func (im *nginxImporter) applyRateLimit(service *config.ServiceConfig, d *ast.Directive) {
+	zone := strings.TrimPrefix(d.Arg(0), "zone=")
+	policy := im.cfg.Security.RateLimit.Policy(im.zones[zone])
+	if policy == nil {
+		im.report(d, "unknown zone "+zone)
+		return
+	}
+
+	if d.Name == "limit_conn" {
+		connections, err := strconv.Atoi(d.Arg(1))
+		switch {
+		case err != nil:
+			im.report(d, "invalid connection limit")
+			return
+		case policy.Connections != 0 && policy.Connections != connections:
+			im.report(d, fmt.Sprintf("policy %s already allows %d connections", policy.Name, policy.Connections))
+		default:
+			policy.Connections = connections
+		}
+	} else {
+		burst, delay := 0, true
+		for _, arg := range d.Args[1:] {
+			switch {
+			case strings.HasPrefix(arg, "burst="):
+				burst, _ = strconv.Atoi(strings.TrimPrefix(arg, "burst="))
+			case arg == "nodelay":
+				delay = false
+			default:
+				im.report(d, "unsupported parameter "+arg)
+			}
+		}
+		if policy.Burst != 0 && (policy.Burst != burst || policy.Delay != delay) {
+			im.report(d, fmt.Sprintf("policy %s already uses burst=%d", policy.Name, policy.Burst))
+		} else {
+			policy.Burst, policy.Delay = burst, delay
+		}
+	}
+
+	for _, name := range service.RateLimit {
+		if name == policy.Name {
+			return
+		}
+	}
+	service.RateLimit = append(service.RateLimit, policy.Name)
+}
+
+func (im *nginxImporter) importUpstream(d *ast.Directive) {
+	upstream := config.UpstreamConfig{Name: d.Arg(0)}
+	for _, child := range directives(d.Block) {
+		switch child.Name {
+		case "server":
+			if strings.HasPrefix(child.Arg(0), "unix:") {
+				im.report(child, "unix socket servers are not supported; use a host:port reachable from the container")
+				continue
+			}
+			server, ok := upstreamServer(child)
+			if !ok {
+				im.report(child, "unsupported server parameter")
+			}
+			if host, _, err := net.SplitHostPort(server.Address); err == nil && isLoopback(host) {
+				im.result.note("upstream %s server %s is the nginx container itself once imported; use a compose service name or host.docker.internal", upstream.Name, server.Address)
+			}
+			upstream.Servers = append(upstream.Servers, server)
+		case "least_conn", "ip_hash":
+			upstream.Strategy = child.Name
+		case "hash":
+			upstream.Strategy = "hash"
+			upstream.HashKey = child.Arg(0)
+			upstream.Consistent = child.Arg(1) == "consistent"
+		case "keepalive":
+			upstream.Keepalive, _ = strconv.Atoi(child.Arg(0))
+		default:
+			im.report(child, "no keynginx setting")
+		}
+	}
+
+	if len(upstream.Servers) == 0 {
+		im.report(d, "no server that can be imported")
+		return
+	}
+
+	im.upstreams[upstream.Name] = true
+	im.cfg.Nginx.Upstreams = append(im.cfg.Nginx.Upstreams, upstream)
+}
+
+func upstreamServer(d *ast.Directive) (config.UpstreamServer, bool) {
+	server := config.UpstreamServer{Address: d.Arg(0)}
+	if _, _, err := net.SplitHostPort(server.Address); err != nil {
+		server.Address += ":80"
+	}
+
+	ok := true
+	for _, arg := range d.Args[1:] {
+		key, value, _ := strings.Cut(arg, "=")
+		switch key {
+		case "weight":
+			server.Weight, _ = strconv.Atoi(value)
+		case "max_fails":
+			server.MaxFails, _ = strconv.Atoi(value)
+		case "fail_timeout":
+			server.FailTimeout = value
+		case "backup":
+			server.Backup = true
+		default:
+			ok = false
+		}
+	}
+	return server, ok
+}
+
+func (im *nginxImporter) importStream(nodes []*ast.Directive) {
+	for _, d := range directives(nodes) {
+		if d.Name != "server" {
+			im.builtinOrReport(d)
+			continue
+		}
+
+		var stream config.StreamConfig
+		for _, child := range directives(d.Block) {
+			switch child.Name {
+			case "listen":
+				port, ok := listenPort(child.Arg(0))
+				if !ok {
+					im.report(child, "only TCP and UDP ports can be imported")
+					continue
+				}
+				stream.Listen = port
+				if hasArg(child, "udp") {
+					stream.Protocol = "udp"
+				}
+				stream.TLS = hasArg(child, "ssl")
+			case "proxy_pass":
+				stream.ProxyPass = child.Arg(0)
+			case "proxy_connect_timeout":
+				stream.ConnectTimeout = child.Arg(0)
+			case "proxy_timeout":
+				stream.Timeout = child.Arg(0)
+			case "ssl_certificate", "ssl_certificate_key":
+				// TLS streams use the project certificate.
+			default:
+				im.builtinOrReport(child)
+			}
+		}
+
+		host, _, err := net.SplitHostPort(stream.ProxyPass)
+		if stream.Listen == 0 || err != nil {
+			im.report(d, "stream servers need a listen port and a host:port proxy_pass")
+			continue
+		}
+
+		base := invalidNameChars.ReplaceAllString(host, "-")
+		if !hostNamePattern.MatchString(host) || isLoopback(host) {
+			base = fmt.Sprintf("stream-%d", stream.Listen)
+		}
+		stream.Name = base
+		for i := 2; im.streamNames[stream.Name]; i++ {
+			stream.Name = fmt.Sprintf("%s-%d", base, i)
+		}
+		im.streamNames[stream.Name] = true
+
+		im.cfg.Nginx.Streams = append(im.cfg.Nginx.Streams, stream)
+	}
+}
+
+func (im *nginxImporter) finish() {
+	if im.httpPort != 0 {
+		im.cfg.Nginx.HTTPPort = im.httpPort
+	}
+
+	// limit_conn_zone zones that no location uses have nothing to limit.
+	policies := im.cfg.Security.RateLimit.Policies[:0]
+	for _, policy := range im.cfg.Security.RateLimit.Policies {
+		if policy.Rate != "" || policy.Connections != 0 {
+			policies = append(policies, policy)
+		}
+	}
+	im.cfg.Security.RateLimit.Policies = policies
+}
+
+// builtinOrReport drops directives keynginx writes itself and reports the
+// rest.
+func (im *nginxImporter) builtinOrReport(d *ast.Directive) {
+	if replacedDirectives[d.Name] {
+		return
+	}
+	if value, ok := builtinDirectives[d.Name]; ok {
+		if strings.Join(d.Args, " ") != value {
+			im.report(d, fmt.Sprintf("keynginx always uses %s %s", d.Name, value))
+		}
+		return
+	}
+	im.unsupported(d)
+}
+
+func (im *nginxImporter) unsupported(d *ast.Directive) {
+	if d.Name == "include" {
+		// Included files that exist were expanded by the parser.
+		switch path.Base(d.Arg(0)) {
+		case "mime.types", "proxy_params":
+		default:
+			im.report(d, "included file not found")
+		}
+		return
+	}
+	im.report(d, "no keynginx setting")
+}
+
+func (im *nginxImporter) report(d *ast.Directive, reason string) {
+	im.result.Issues = append(im.result.Issues, Issue{
+		File:      d.File,
+		Line:      d.Line,
+		Directive: summary(d),
+		Reason:    reason,
+	})
+}
+
+// summary is the directive as written on one line, with block contents
+// elided.
+func summary(d *ast.Directive) string {
+	text := strings.TrimSuffix(ast.New(d.Name, d.Args...).String(), ";")
+	if d.IsBlock {
+		return text + " { ... }"
+	}
+	return text
+}
+
+// redirectsToHTTPS reports whether a plain HTTP server only redirects, such
+// as the blocks certbot writes.
+func redirectsToHTTPS(server *ast.Directive) bool {
+	redirects := false
+	for _, d := range directives(server.Block) {
+		switch d.Name {
+		case "listen", "server_name", "access_log", "error_log":
+		case "return":
+			redirects = redirects || strings.HasPrefix(d.Arg(len(d.Args)-1), "https://")
+		case "if":
+			for _, child := range directives(d.Block) {
+				if child.Name != "return" {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+	return redirects
+}
+
+func listenPort(address string) (int, bool) {
+	if strings.HasPrefix(address, "unix:") {
+		return 0, false
+	}
+	if i := strings.LastIndexByte(address, ':'); i >= 0 && !strings.HasSuffix(address, "]") {
+		address = address[i+1:]
+	}
+	port, err := strconv.Atoi(address)
+	if err != nil {
+		// A listen address without a port uses 80.
+		return 80, true
+	}
+	return port, port > 0 && port <= 65535
+}
+
+func splitLocation(location *ast.Directive) (string, string) {
+	if len(location.Args) == 2 {
+		return location.Args[0], location.Args[1]
+	}
+	return "", location.Arg(0)
+}
+
+// nameFromPath derives a service name from the first word of a location
+// path, e.g. "api" for /api/ or ~ ^/api/(v1|v2).
+func nameFromPath(locationPath, fallback string) string {
+	if i := strings.IndexByte(locationPath, '/'); i >= 0 {
+		locationPath = locationPath[i:]
+	}
+	if word := nameWordPattern.FindString(locationPath); word != "" {
+		return word
+	}
+	return fallback
+}
+
+// policyKey translates a zone key variable into a rate limit policy key.
+func policyKey(variable string) string {
+	switch variable {
+	case "$binary_remote_addr", "$remote_addr":
+		return ""
+	case "$http_x_api_key":
+		return "api_key"
+	}
+
+	if name, ok := strings.CutPrefix(variable, "$http_"); ok && headerVariableKey.MatchString(name) {
+		words := strings.Split(name, "_")
+		for i, word := range words {
+			if word != "" {
+				words[i] = strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+		return "header:" + strings.Join(words, "-")
+	}
+	return variable
+}
+
+func copyService(service config.ServiceConfig) config.ServiceConfig {
+	service.Proxy.SetHeaders = copyMap(service.Proxy.SetHeaders)
+	service.Proxy.HideHeaders = append([]string(nil), service.Proxy.HideHeaders...)
+	service.Proxy.NextUpstream = append([]string(nil), service.Proxy.NextUpstream...)
+	service.Static.Precompressed = append([]string(nil), service.Static.Precompressed...)
+	service.RateLimit = append([]string(nil), service.RateLimit...)
+	service.Access.Allow = append([]string(nil), service.Access.Allow...)
+	service.Access.Deny = append([]string(nil), service.Access.Deny...)
+	return service
+}
+
+func copyMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
+
+func onOff(value string) *bool {
+	enabled := value == "on"
+	return &enabled
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func isURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "$scheme://")
+}
+
+func hasArg(d *ast.Directive, arg string) bool {
+	for _, value := range d.Args {
+		if value == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func directives(nodes []*ast.Directive) []*ast.Directive {
+	var result []*ast.Directive
+	for _, d := range nodes {
+		if !d.IsComment() && !d.IsBlank() {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func filter(nodes []*ast.Directive, name string) []*ast.Directive {
+	var result []*ast.Directive
+	for _, d := range directives(nodes) {
+		if d.Name == name {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,266 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+)
+
+func TestImportNginx(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		check func(t *testing.T, result *Result)
+	}{
+		{
+			name: "include globs",
+			files: map[string]string{
+				"nginx.conf": `
+events {}
+http {
+    include mime.types;
+    include conf.d/*.conf;
+}`,
+				"conf.d/b.conf": `
+server {
+    listen 443 ssl;
+    server_name shop.example.com;
+    location / { proxy_pass http://shop:3000; }
+}`,
+				"conf.d/a.conf": `
+server {
+    listen 443 ssl;
+    server_name example.com;
+    location /api/ { proxy_pass http://api:8000; }
+}`,
+			},
+			check: func(t *testing.T, result *Result) {
+				cfg := result.Config
+				if cfg.Project.Domain != "example.com" {
+					t.Errorf("domain = %q, want the server of a.conf, which sorts first", cfg.Project.Domain)
+				}
+				if got := serviceNames(cfg.Nginx.Services); !reflect.DeepEqual(got, []string{"api"}) {
+					t.Errorf("services = %q", got)
+				}
+				if len(cfg.Nginx.Sites) != 1 || cfg.Nginx.Sites[0].Name != "shop-example-com" {
+					t.Fatalf("sites = %+v", cfg.Nginx.Sites)
+				}
+				if got := serviceNames(cfg.Nginx.Sites[0].Services); !reflect.DeepEqual(got, []string{"shop"}) {
+					t.Errorf("site services = %q", got)
+				}
+				if len(result.Issues) != 0 {
+					t.Errorf("issues = %v, want none for mime.types", result.Issues)
+				}
+			},
+		},
+		{
+			name: "upstreams",
+			files: map[string]string{
+				"nginx.conf": `
+upstream backend {
+    least_conn;
+    keepalive 16;
+    server app1:8080 weight=3 max_fails=2 fail_timeout=10s;
+    server app2 backup;
+    server app3:8080 slow_start=30s;
+    zone backend 64k;
+}
+server {
+    listen 443 ssl;
+    server_name example.com;
+    location / { proxy_pass http://backend; }
+}`,
+			},
+			check: func(t *testing.T, result *Result) {
+				want := []config.UpstreamConfig{{
+					Name:      "backend",
+					Strategy:  "least_conn",
+					Keepalive: 16,
+					Servers: []config.UpstreamServer{
+						{Address: "app1:8080", Weight: 3, MaxFails: 2, FailTimeout: "10s"},
+						{Address: "app2:80", Backup: true},
+						{Address: "app3:8080"},
+					},
+				}}
+				if got := result.Config.Nginx.Upstreams; !reflect.DeepEqual(got, want) {
+					t.Errorf("upstreams = %+v, want %+v", got, want)
+				}
+				services := result.Config.Nginx.Services
+				if len(services) != 1 || services[0].Name != "backend" || services[0].Upstream != "backend" {
+					t.Errorf("services = %+v", services)
+				}
+				wantIssues(t, result, "server app3:8080 slow_start=30s", "zone backend 64k")
+			},
+		},
+		{
+			name: "unix sockets",
+			files: map[string]string{
+				"nginx.conf": `
+upstream sockets {
+    server unix:/run/app.sock;
+}
+upstream mixed {
+    server unix:/run/app.sock;
+    server 127.0.0.1:9000;
+}
+server {
+    listen 443 ssl;
+    server_name example.com;
+    location /a/ { proxy_pass http://mixed; }
+}`,
+			},
+			check: func(t *testing.T, result *Result) {
+				upstreams := result.Config.Nginx.Upstreams
+				if len(upstreams) != 1 || upstreams[0].Name != "mixed" {
+					t.Fatalf("upstreams = %+v, want only mixed", upstreams)
+				}
+				if got := upstreams[0].Servers; !reflect.DeepEqual(got, []config.UpstreamServer{{Address: "127.0.0.1:9000"}}) {
+					t.Errorf("mixed servers = %+v", got)
+				}
+				wantIssues(t, result, "server unix:/run/app.sock", "upstream sockets { ... }", "server unix:/run/app.sock")
+				if !hasNote(result, "upstream mixed server 127.0.0.1:9000") {
+					t.Errorf("notes = %q, want the loopback server noted", result.Notes)
+				}
+			},
+		},
+		{
+			name: "redirect servers",
+			files: map[string]string{
+				"nginx.conf": `
+server {
+    listen 8080;
+    server_name example.com;
+    return 301 https://$host$request_uri;
+}
+server {
+    listen 8443 ssl;
+    server_name example.com;
+    location = /old { return 302 /new; }
+    location /docs/ { return 301 https://docs.example.com/; }
+    location / { proxy_pass http://web:3000; }
+}`,
+			},
+			check: func(t *testing.T, result *Result) {
+				nginx := result.Config.Nginx
+				if nginx.HTTPPort != 8080 || nginx.HTTPSPort != 8443 {
+					t.Errorf("ports = %d/%d, want 8080/8443", nginx.HTTPPort, nginx.HTTPSPort)
+				}
+				if len(nginx.Sites) != 0 {
+					t.Errorf("sites = %+v, want the redirect server dropped", nginx.Sites)
+				}
+				keepQuery := false
+				want := []config.RedirectRule{
+					{From: "/old", To: "/new", Code: 302, PreserveQuery: &keepQuery},
+					{From: `^/docs/`, To: "https://docs.example.com/", Match: "regex", PreserveQuery: &keepQuery},
+				}
+				if !reflect.DeepEqual(nginx.Redirects, want) {
+					t.Errorf("redirects = %+v, want %+v", nginx.Redirects, want)
+				}
+				if got := serviceNames(nginx.Services); !reflect.DeepEqual(got, []string{"web"}) {
+					t.Errorf("services = %q", got)
+				}
+				wantIssues(t, result)
+			},
+		},
+		{
+			name: "unsupported directives",
+			files: map[string]string{
+				"nginx.conf": `
+worker_processes 4;
+load_module modules/ngx_http_geoip_module.so;
+events { worker_connections 1024; }
+http {
+    include /etc/nginx/missing.conf;
+    geo $blocked { default 0; }
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        location /status { stub_status; }
+        location /app/ {
+            proxy_pass http://app:3000;
+            sub_filter a b;
+            location /app/nested/ { proxy_pass http://other:80; }
+        }
+        location /legacy/ { proxy_pass fastcgi://php:9000; }
+    }
+}`,
+			},
+			check: func(t *testing.T, result *Result) {
+				wantIssues(t, result,
+					"load_module modules/ngx_http_geoip_module.so",
+					"include /etc/nginx/missing.conf",
+					"geo $blocked { ... }",
+					"stub_status",
+					"location /status { ... }",
+					"sub_filter a b",
+					"location /app/nested/ { ... }",
+					"proxy_pass fastcgi://php:9000",
+				)
+				if got := serviceNames(result.Config.Nginx.Services); !reflect.DeepEqual(got, []string{"app"}) {
+					t.Errorf("services = %q", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for name, content := range tt.files {
+			file := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := ImportNginx(filepath.Join(dir, "nginx.conf"))
+		if err != nil {
+			t.Errorf("%s: ImportNginx failed: %v", tt.name, err)
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) { tt.check(t, result) })
+	}
+}
+
+func serviceNames(services []config.ServiceConfig) []string {
+	var names []string
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+	return names
+}
+
+// wantIssues compares the reported directives, in order.
+func wantIssues(t *testing.T, result *Result, directives ...string) {
+	t.Helper()
+	var got []string
+	for _, issue := range result.Issues {
+		got = append(got, issue.Directive)
+	}
+	if !reflect.DeepEqual(got, directives) && len(got)+len(directives) > 0 {
+		t.Errorf("issues:\n%s\nwant directives %q", strings.Join(issueStrings(result.Issues), "\n"), directives)
+	}
+}
+
+func issueStrings(issues []Issue) []string {
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+	return lines
+}
+
+func hasNote(result *Result, prefix string) bool {
+	for _, note := range result.Notes {
+		if strings.HasPrefix(note, prefix) {
+			return true
+		}
+	}
+	return false
+}
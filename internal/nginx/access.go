@@ -0,0 +1,130 @@
+package nginx
+
+import (
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// GetAccessConfig builds the server-level access directives.
+func GetAccessConfig(security *config.SecurityConfig) []*ast.Directive {
+	nodes := aclDirectives(security.Access)
+	if security.BasicAuth.Enabled {
+		nodes = append(nodes, basicAuthDirectives(security.BasicAuth.RealmOrDefault())...)
+	}
+	return nodes
+}
+
+// GetRealIPConfig makes $remote_addr the original client address when
+// requests arrive through trusted load balancers, so logs, rate limits and
+// allow/deny lists see the real client.
+func GetRealIPConfig(cfg *config.Config) []*ast.Directive {
+	if len(cfg.Security.TrustedProxies) == 0 {
+		return nil
+	}
+
+	nodes := []*ast.Directive{ast.Comment("Trusted proxies")}
+	for _, proxy := range cfg.Security.TrustedProxies {
+		nodes = append(nodes, ast.New("set_real_ip_from", proxy))
+	}
+	return append(nodes,
+		ast.New("real_ip_header", cfg.RealIPHeader()),
+		ast.New("real_ip_recursive", "on"),
+	)
+}
+
+// aclDirectives renders deny entries before allow entries, so a denied
+// address inside an allowed range stays denied. An allow list denies everyone
+// else.
+func aclDirectives(access config.AccessConfig) []*ast.Directive {
+	var nodes []*ast.Directive
+	for _, entry := range access.Deny {
+		nodes = append(nodes, ast.New("deny", entry))
+	}
+	for _, entry := range access.Allow {
+		nodes = append(nodes, ast.New("allow", entry))
+	}
+	if len(access.Allow) > 0 && !containsString(access.Allow, "all") {
+		nodes = append(nodes, ast.New("deny", "all"))
+	}
+	return nodes
+}
+
+// serviceACLDirectives renders a service's lists followed by the
+// server-level ones. allow and deny in a location replace everything
+// inherited, so the project entries are repeated; nginx stops at the first
+// match, so the service entries take precedence.
+func serviceACLDirectives(security *config.SecurityConfig, access config.AccessConfig) []*ast.Directive {
+	if access.IsEmpty() {
+		return nil
+	}
+
+	var nodes []*ast.Directive
+	for _, entry := range access.Deny {
+		nodes = append(nodes, ast.New("deny", entry))
+	}
+	for _, entry := range access.Allow {
+		nodes = append(nodes, ast.New("allow", entry))
+	}
+	if containsString(access.Allow, "all") {
+		return nodes
+	}
+
+	for _, entry := range security.Access.Deny {
+		nodes = append(nodes, ast.New("deny", entry))
+	}
+	for _, entry := range security.Access.Allow {
+		nodes = append(nodes, ast.New("allow", entry))
+	}
+	if len(access.Allow)+len(security.Access.Allow) > 0 && !containsString(security.Access.Allow, "all") {
+		nodes = append(nodes, ast.New("deny", "all"))
+	}
+	return nodes
+}
+
+// GetPublicAccess builds the overrides that keep the health check and
+// security.txt reachable when the server requires a password.
+func GetPublicAccess(security *config.SecurityConfig) []*ast.Directive {
+	if !security.BasicAuth.Enabled {
+		return nil
+	}
+	return []*ast.Directive{ast.New("auth_basic", "off")}
+}
+
+// accessSections builds the per-location ACL, basic auth, rate limits and
+// access logs shared by every service type, each under its own comment.
+func accessSections(cfg *config.Config, security *config.SecurityConfig, site string, service config.ServiceConfig) []*ast.Directive {
+	var nodes []*ast.Directive
+	nodes = appendSection(nodes, "Access control (service entries first, then the server-level lists)", serviceACLDirectives(security, service.Access))
+	nodes = appendSection(nodes, "Basic authentication", authDirectives(security, service))
+	nodes = appendSection(nodes, "Rate limiting", rateLimitDirectives(cfg, service))
+	nodes = appendSection(nodes, "Access log", serviceLogDirectives(cfg, site, service))
+	return nodes
+}
+
+func authDirectives(security *config.SecurityConfig, service config.ServiceConfig) []*ast.Directive {
+	auth := service.BasicAuth
+	realm := auth.Realm
+	if realm == "" {
+		realm = security.BasicAuth.RealmOrDefault()
+	}
+
+	switch {
+	case auth.Enabled == nil:
+		if auth.Realm != "" && security.BasicAuth.Enabled {
+			return []*ast.Directive{ast.New("auth_basic", realm)}
+		}
+		return nil
+	case *auth.Enabled:
+		return basicAuthDirectives(realm)
+	case security.BasicAuth.Enabled:
+		return []*ast.Directive{ast.New("auth_basic", "off")}
+	}
+	return nil
+}
+
+func basicAuthDirectives(realm string) []*ast.Directive {
+	return []*ast.Directive{
+		ast.New("auth_basic", realm),
+		ast.New("auth_basic_user_file", config.HtpasswdPath),
+	}
+}
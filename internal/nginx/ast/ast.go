@@ -0,0 +1,191 @@
+// Package ast is a structured model of nginx configuration files. Generators
+// build Directive trees, the printer turns them into deterministic, correctly
+// quoted and indented text, and the parser reads text back so the result can
+// be queried.
+package ast
+
+import "strings"
+
+// Directive is a simple directive (name and arguments), a block directive
+// (IsBlock, with children in Block), a comment or a blank line.
+type Directive struct {
+	Name    string
+	Args    []string
+	IsBlock bool
+	Block   []*Directive
+	Comment string
+	Line    int
+	// File is the file the directive was read from by ParseFile.
+	File string
+}
+
+// Config is a parsed or generated configuration file.
+type Config struct {
+	Directives []*Directive
+}
+
+func New(name string, args ...string) *Directive {
+	return &Directive{Name: name, Args: args}
+}
+
+func NewBlock(name string, args ...string) *Directive {
+	return &Directive{Name: name, Args: args, IsBlock: true}
+}
+
+// Comment creates a comment line; text is printed after "# ". Comment holds
+// the full line including the "#", as read by the parser.
+func Comment(text string) *Directive {
+	return &Directive{Comment: "# " + text}
+}
+
+// Blank creates an empty separator line.
+func Blank() *Directive {
+	return &Directive{}
+}
+
+// Append adds children to a block and returns it, so blocks can be built in
+// one expression.
+func (d *Directive) Append(children ...*Directive) *Directive {
+	d.IsBlock = true
+	d.Block = append(d.Block, children...)
+	return d
+}
+
+func (d *Directive) IsComment() bool {
+	return d.Comment != ""
+}
+
+// IsBlank reports whether d is a separator line. Directives may have an
+// empty name, such as the ” key of a map block, but always have arguments.
+func (d *Directive) IsBlank() bool {
+	return d.Name == "" && d.Comment == "" && len(d.Args) == 0 && !d.IsBlock
+}
+
+func (d *Directive) isDirective() bool {
+	return !d.IsComment() && !d.IsBlank()
+}
+
+// Arg returns the i-th argument, or "" when there are fewer arguments.
+func (d *Directive) Arg(i int) string {
+	if i < 0 || i >= len(d.Args) {
+		return ""
+	}
+	return d.Args[i]
+}
+
+// Children returns the direct children with the given name.
+func (d *Directive) Children(name string) []*Directive {
+	return filter(d.Block, name)
+}
+
+// Child returns the first direct child with the given name, or nil.
+func (d *Directive) Child(name string) *Directive {
+	if children := d.Children(name); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// Find returns every directive below d with the given name, at any depth,
+// in document order.
+func (d *Directive) Find(name string) []*Directive {
+	return find(d.Block, name)
+}
+
+// Query follows a path of block names from d, see Config.Query.
+func (d *Directive) Query(path ...string) []*Directive {
+	return query(d.Block, path)
+}
+
+func (c *Config) Find(name string) []*Directive {
+	return find(c.Directives, name)
+}
+
+// Query follows a path of directive names from the top level. Each segment
+// is a name optionally followed by leading arguments that must match, e.g.
+//
+//	cfg.Query("http", "server", "location /api")
+//	cfg.Query("http", "server", "add_header Strict-Transport-Security")
+func (c *Config) Query(path ...string) []*Directive {
+	return query(c.Directives, path)
+}
+
+// Walk calls fn for every directive in document order, with the chain of
+// enclosing blocks. Returning false skips the directive's children.
+func (c *Config) Walk(fn func(d *Directive, parents []*Directive) bool) {
+	walk(c.Directives, nil, fn)
+}
+
+func walk(nodes []*Directive, parents []*Directive, fn func(*Directive, []*Directive) bool) {
+	for _, d := range nodes {
+		if !d.isDirective() {
+			continue
+		}
+		if fn(d, parents) && d.IsBlock {
+			walk(d.Block, append(parents[:len(parents):len(parents)], d), fn)
+		}
+	}
+}
+
+func filter(nodes []*Directive, name string) []*Directive {
+	var result []*Directive
+	for _, d := range nodes {
+		if d.isDirective() && d.Name == name {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func find(nodes []*Directive, name string) []*Directive {
+	var result []*Directive
+	for _, d := range nodes {
+		if d.isDirective() && d.Name == name {
+			result = append(result, d)
+		}
+		if d.IsBlock {
+			result = append(result, find(d.Block, name)...)
+		}
+	}
+	return result
+}
+
+func query(nodes []*Directive, path []string) []*Directive {
+	if len(path) == 0 {
+		return nil
+	}
+
+	fields := strings.Fields(path[0])
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var matches []*Directive
+	for _, d := range filter(nodes, fields[0]) {
+		if hasArgs(d, fields[1:]) {
+			matches = append(matches, d)
+		}
+	}
+
+	if len(path) == 1 {
+		return matches
+	}
+
+	var result []*Directive
+	for _, d := range matches {
+		result = append(result, query(d.Block, path[1:])...)
+	}
+	return result
+}
+
+func hasArgs(d *Directive, args []string) bool {
+	if len(args) > len(d.Args) {
+		return false
+	}
+	for i, arg := range args {
+		if d.Args[i] != arg {
+			return false
+		}
+	}
+	return true
+}
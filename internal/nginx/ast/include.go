@@ -0,0 +1,107 @@
+package ast
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseFile reads an nginx configuration from disk and expands its include
+// directives in place, recording the source file of every directive.
+// Relative include paths are resolved from the directory of the top-level
+// file, as nginx resolves them from its prefix, and glob patterns expand to
+// every match in name order. An include of a single file that does not exist,
+// such as /etc/nginx/mime.types on a workstation, is kept as a directive so
+// callers can decide how to report it.
+func ParseFile(path string) (*Config, error) {
+	r := &includeResolver{base: filepath.Dir(path)}
+	directives, err := r.parse(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Directives: directives}, nil
+}
+
+type includeResolver struct {
+	base string
+}
+
+func (r *includeResolver) parse(path string, stack []string) ([]*Directive, error) {
+	for _, file := range stack {
+		if file == path {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), path)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return r.expand(cfg.Directives, path, append(stack[:len(stack):len(stack)], path))
+}
+
+func (r *includeResolver) expand(nodes []*Directive, file string, stack []string) ([]*Directive, error) {
+	var result []*Directive
+	for _, d := range nodes {
+		d.File = file
+
+		if d.isDirective() && d.Name == "include" && !d.IsBlock && len(d.Args) == 1 {
+			files, err := r.resolve(d.Args[0])
+			if err != nil {
+				return nil, fmt.Errorf("%s: line %d: %w", file, d.Line, err)
+			}
+			if files != nil {
+				for _, included := range files {
+					children, err := r.parse(included, stack)
+					if err != nil {
+						return nil, err
+					}
+					result = append(result, children...)
+				}
+				continue
+			}
+		}
+
+		if d.IsBlock {
+			children, err := r.expand(d.Block, file, stack)
+			if err != nil {
+				return nil, err
+			}
+			d.Block = children
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// resolve returns the files an include pattern refers to, or nil when it
+// names a single file that does not exist. A glob without matches is valid
+// in nginx and resolves to no files.
+func (r *includeResolver) resolve(pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(r.base, pattern)
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+	}
+	if matches == nil {
+		matches = []string{}
+	}
+	return matches, nil
+}
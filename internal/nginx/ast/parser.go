@@ -0,0 +1,192 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse reads an nginx configuration. Comments and single blank lines
+// between directives are kept, so a parsed file prints back recognisably.
+func Parse(src []byte) (*Config, error) {
+	p := &parser{src: string(src), line: 1}
+	directives, err := p.parseBlock(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Directives: directives}, nil
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseBlock(nested bool) ([]*Directive, error) {
+	var (
+		nodes    []*Directive
+		words    []string
+		line     int
+		newlines int
+	)
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\n':
+			p.line++
+			p.pos++
+			if len(words) == 0 {
+				newlines++
+				if newlines == 2 {
+					nodes = append(nodes, Blank())
+				}
+			}
+
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+
+		case c == '#':
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			text := strings.TrimRight(p.src[p.pos:p.pos+end], " \t\r")
+			nodes = append(nodes, &Directive{Comment: text, Line: p.line})
+			p.pos += end
+			newlines = 0
+
+		case c == ';':
+			if len(words) == 0 {
+				return nil, p.errorf(`unexpected ";"`)
+			}
+			nodes = append(nodes, &Directive{Name: words[0], Args: words[1:], Line: line})
+			words = nil
+			p.pos++
+			newlines = 0
+
+		case c == '{':
+			if len(words) == 0 {
+				return nil, p.errorf(`unexpected "{"`)
+			}
+			block := &Directive{Name: words[0], Args: words[1:], IsBlock: true, Line: line}
+			words = nil
+			p.pos++
+			children, err := p.parseBlock(true)
+			if err != nil {
+				return nil, err
+			}
+			block.Block = children
+			nodes = append(nodes, block)
+			newlines = 0
+
+		case c == '}':
+			if len(words) > 0 {
+				return nil, p.errorf(`unexpected "}", expecting ";" after %q`, words[0])
+			}
+			if !nested {
+				return nil, p.errorf(`unexpected "}"`)
+			}
+			p.pos++
+			return nodes, nil
+
+		default:
+			if len(words) == 0 {
+				line = p.line
+			}
+			word, err := p.readWord()
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, word)
+			newlines = 0
+		}
+	}
+
+	if len(words) > 0 {
+		return nil, p.errorf(`unexpected end of file, expecting ";" or "{" after %q`, words[0])
+	}
+	if nested {
+		return nil, p.errorf(`unexpected end of file, expecting "}"`)
+	}
+	return nodes, nil
+}
+
+func (p *parser) readWord() (string, error) {
+	start := p.pos
+	if quote := p.src[p.pos]; quote == '"' || quote == '\'' {
+		startLine := p.line
+		p.pos++
+		for p.pos < len(p.src) {
+			switch p.src[p.pos] {
+			case '\\':
+				p.pos += 2
+				continue
+			case '\n':
+				p.line++
+			case quote:
+				p.pos++
+				return unescape(p.src[start+1 : p.pos-1]), nil
+			}
+			p.pos++
+		}
+		p.line = startLine
+		return "", p.errorf("unterminated string")
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\':
+			p.pos += 2
+			continue
+		case c == '{' && p.pos > start && p.src[p.pos-1] == '$':
+			// ${variable} is part of the word, not a block.
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ';' || c == '{' || c == '}':
+			return unescape(p.src[start:p.pos]), nil
+		}
+		p.pos++
+	}
+	if p.pos > len(p.src) {
+		p.pos = len(p.src)
+	}
+	return unescape(p.src[start:p.pos]), nil
+}
+
+// unescape applies the escape sequences nginx understands; any other
+// backslash is kept, which preserves regex escapes.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '"', '\'', '\\':
+				b.WriteByte(s[i+1])
+				i++
+				continue
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				b.WriteByte('\t')
+				i++
+				continue
+			case 'r':
+				b.WriteByte('\r')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
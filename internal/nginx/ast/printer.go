@@ -0,0 +1,144 @@
+package ast
+
+import "strings"
+
+const (
+	indentUnit = "    "
+	// Directives longer than wrapWidth with more than wrapArgs arguments put
+	// each further argument on its own line (log_format, gzip_types, ...).
+	wrapWidth = 100
+	wrapArgs  = 3
+)
+
+// String prints the whole configuration, ending in a newline.
+func (c *Config) String() string {
+	return Print(c.Directives, 0) + "\n"
+}
+
+// String prints the directive and its children at the top level.
+func (d *Directive) String() string {
+	return Print([]*Directive{d}, 0)
+}
+
+// Print renders directives at the given nesting depth, four spaces per level.
+// Blank lines are collapsed and dropped at the start and end of every block,
+// so the output only depends on the tree.
+func Print(nodes []*Directive, depth int) string {
+	var b strings.Builder
+	printNodes(&b, nodes, depth)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func printNodes(b *strings.Builder, nodes []*Directive, depth int) {
+	indent := strings.Repeat(indentUnit, depth)
+	pendingBlank := false
+	printed := false
+
+	for _, d := range nodes {
+		if d.IsBlank() {
+			pendingBlank = printed
+			continue
+		}
+		if pendingBlank {
+			b.WriteString("\n")
+			pendingBlank = false
+		}
+		printed = true
+
+		if d.IsComment() {
+			b.WriteString(indent + d.Comment + "\n")
+			continue
+		}
+
+		b.WriteString(indent)
+		writeDirective(b, d, indent)
+
+		if !d.IsBlock {
+			b.WriteString(";\n")
+			continue
+		}
+		b.WriteString(" {\n")
+		printNodes(b, d.Block, depth+1)
+		b.WriteString(indent + "}\n")
+	}
+}
+
+func writeDirective(b *strings.Builder, d *Directive, indent string) {
+	words := make([]string, 0, len(d.Args)+1)
+	words = append(words, Quote(d.Name))
+	for _, arg := range d.Args {
+		words = append(words, Quote(arg))
+	}
+
+	line := strings.Join(words, " ")
+	if len(d.Args) <= wrapArgs || len(indent)+len(line) <= wrapWidth {
+		b.WriteString(line)
+		return
+	}
+
+	b.WriteString(words[0] + " " + words[1])
+	for _, word := range words[2:] {
+		b.WriteString("\n" + indent + indentUnit + word)
+	}
+}
+
+// Quote returns arg as an nginx token: bare when that is unambiguous,
+// otherwise double-quoted (single-quoted when the value contains double
+// quotes only) with quotes, backslashes and control characters escaped.
+func Quote(arg string) string {
+	if !needsQuotes(arg) {
+		return arg
+	}
+
+	quote := byte('"')
+	if strings.Contains(arg, `"`) && !strings.Contains(arg, "'") {
+		quote = '\''
+	}
+
+	var b strings.Builder
+	b.WriteByte(quote)
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		switch {
+		case c == quote:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\\' && escapesNext(arg, i):
+			b.WriteString(`\\`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(quote)
+	return b.String()
+}
+
+func needsQuotes(arg string) bool {
+	if arg == "" || arg[0] == '#' {
+		return true
+	}
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case ' ', '\t', '\r', '\n', ';', '{', '}', '"', '\'':
+			return true
+		case '\\':
+			if escapesNext(arg, i) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// escapesNext reports whether the backslash at i would be read as an escape
+// sequence by nginx. Other backslashes, such as regex escapes like \., are
+// kept literally and need no escaping.
+func escapesNext(arg string, i int) bool {
+	return i+1 == len(arg) || strings.IndexByte(`"'\ntr`, arg[i+1]) >= 0
+}
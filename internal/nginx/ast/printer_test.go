@@ -0,0 +1,121 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"/api", "/api"},
+		{"$host$request_uri", "$host$request_uri"},
+		{`~^/(.+)\.php$`, `~^/(.+)\.php$`},
+		{"", `""`},
+		{"#anchor", `"#anchor"`},
+		{"max-age=31536000; includeSubDomains", `"max-age=31536000; includeSubDomains"`},
+		{"${var}", `"${var}"`},
+		{`say "hi"`, `'say "hi"'`},
+		{`it's "quoted"`, `"it's \"quoted\""`},
+		{"line\nbreak\ttab", `"line\nbreak\ttab"`},
+		{`C:\`, `"C:\\"`},
+		{`\n`, `"\\n"`},
+		{`a\\b`, `"a\\\b"`},
+		{"/a { return 200; } location /b", `"/a { return 200; } location /b"`},
+	}
+
+	for _, tt := range tests {
+		if got := Quote(tt.arg); got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	args := []string{
+		"/api",
+		"",
+		"$http_upgrade",
+		"${scheme}://$host",
+		`"$remote_addr - $remote_user"`,
+		`'single' and "double"`,
+		`\.(png|jpe?g)$`,
+		`^/old/(.*)\/$`,
+		`trailing\`,
+		`\"escaped\"`,
+		`C:\new\table`,
+		`a\\b`,
+		"multi\nline\r\n\ttext",
+		"{ return 200; }",
+		"#not a comment",
+		"semi;colon",
+	}
+
+	for _, arg := range args {
+		parsed, err := Parse([]byte("directive " + Quote(arg) + ";"))
+		if err != nil {
+			t.Errorf("Parse(Quote(%q)) failed: %v", arg, err)
+			continue
+		}
+		if len(parsed.Directives) != 1 {
+			t.Errorf("Parse(Quote(%q)) = %d directives, want 1", arg, len(parsed.Directives))
+			continue
+		}
+		if got := parsed.Directives[0].Args; !reflect.DeepEqual(got, []string{arg}) {
+			t.Errorf("Parse(Quote(%q)) args = %q", arg, got)
+		}
+	}
+}
+
+func TestPrintParseRoundTrip(t *testing.T) {
+	nodes := []*Directive{
+		Comment("Generated"),
+		New("worker_processes", "auto"),
+		Blank(),
+		NewBlock("http").Append(
+			New("log_format", "main", `$remote_addr "$request" `, `$status`),
+			NewBlock("server").Append(
+				New("server_name", "example.com", "*.example.com"),
+				New("add_header", "Content-Security-Policy", "default-src 'self'; img-src data:", "always"),
+				NewBlock("location", "~*", `\.(css|js)$`).Append(
+					New("expires", "30d"),
+				),
+				NewBlock("location", "/my files/").Append(
+					New("return", "200", "ok\n"),
+				),
+			),
+		),
+	}
+
+	printed := Print(nodes, 0)
+	parsed, err := Parse([]byte(printed))
+	if err != nil {
+		t.Fatalf("Parse(Print()) failed: %v\n%s", err, printed)
+	}
+	if reprinted := parsed.String(); reprinted != printed+"\n" {
+		t.Errorf("printing is not stable:\n%s\nthen:\n%s", printed, reprinted)
+	}
+
+	server := parsed.Query("http", "server")
+	if len(server) != 1 {
+		t.Fatalf("Query(http, server) = %d directives, want 1", len(server))
+	}
+	header := server[0].Child("add_header")
+	if header == nil || header.Arg(1) != "default-src 'self'; img-src data:" {
+		t.Errorf("add_header = %v", header)
+	}
+
+	var locations [][]string
+	for _, location := range server[0].Children("location") {
+		locations = append(locations, location.Args)
+	}
+	want := [][]string{{"~*", `\.(css|js)$`}, {"/my files/"}}
+	if !reflect.DeepEqual(locations, want) {
+		t.Errorf("location args = %q, want %q", locations, want)
+	}
+	if ret := server[0].Children("location")[1].Child("return"); ret == nil || ret.Arg(1) != "ok\n" {
+		t.Errorf("return = %v", ret)
+	}
+}
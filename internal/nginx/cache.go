@@ -0,0 +1,80 @@
+package nginx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+func GetCacheConfig(cfg *config.Config) []*ast.Directive {
+	if !cfg.CacheEnabled() {
+		return nil
+	}
+
+	cache := cfg.Nginx.Cache
+	size := cache.Size
+	if size == "" {
+		size = "10m"
+	}
+	inactive := cache.Inactive
+	if inactive == "" {
+		inactive = "60m"
+	}
+
+	args := []string{config.CacheDir, "levels=1:2", fmt.Sprintf("keys_zone=%s:%s", config.CacheZone, size), "inactive=" + inactive}
+	if cache.MaxSize != "" {
+		args = append(args, "max_size="+cache.MaxSize)
+	}
+	args = append(args, "use_temp_path=off")
+
+	return []*ast.Directive{
+		ast.Comment(fmt.Sprintf("Proxy cache (stored in the %s volume)", cfg.CacheVolume())),
+		ast.New("proxy_cache_path", args...),
+	}
+}
+
+func cacheDirectives(cache config.CacheSettings) []*ast.Directive {
+	nodes := []*ast.Directive{
+		ast.New("proxy_cache", config.CacheZone),
+		ast.New("proxy_cache_key", cache.Key),
+	}
+
+	for _, statuses := range sortedKeys(cache.Valid) {
+		nodes = append(nodes, ast.New("proxy_cache_valid", append(strings.Fields(statuses), cache.Valid[statuses])...))
+	}
+
+	if len(cache.Bypass) > 0 {
+		nodes = append(nodes,
+			ast.New("proxy_cache_bypass", cache.Bypass...),
+			ast.New("proxy_no_cache", cache.Bypass...),
+		)
+	}
+
+	if len(cache.UseStale) > 0 {
+		nodes = append(nodes, ast.New("proxy_cache_use_stale", cache.UseStale...))
+		if containsString(cache.UseStale, "updating") {
+			nodes = append(nodes, ast.New("proxy_cache_background_update", "on"))
+		}
+	}
+
+	if cache.Lock {
+		nodes = append(nodes, ast.New("proxy_cache_lock", "on"))
+	}
+	if cache.MinUses > 0 {
+		nodes = append(nodes, ast.New("proxy_cache_min_uses", strconv.Itoa(cache.MinUses)))
+	}
+
+	return nodes
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,416 @@
+package nginx
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// ConfigPath is where the generated nginx.conf is mounted in the container.
+const ConfigPath = "/etc/nginx/nginx.conf"
+
+var testMessage = regexp.MustCompile(`^nginx: \[(\w+)\] (.*?)(?: in (\S+):(\d+))?$`)
+
+// Diagnostic is a message printed by nginx -t. Line, Directive and Setting
+// are set when the message points into the generated nginx.conf; Setting is
+// the keynginx.yaml path that produced the directive, when it can be told.
+type Diagnostic struct {
+	Level     string
+	Message   string
+	Line      int
+	Directive string
+	Setting   string
+}
+
+func (d Diagnostic) String() string {
+	message := d.Message
+	if d.Line > 0 {
+		message = fmt.Sprintf("nginx.conf:%d: %s", d.Line, message)
+	}
+	if d.Setting != "" {
+		message += " (from " + d.Setting + ")"
+	}
+	return message
+}
+
+// Diagnose reads the output of nginx -t run against generated.
+func Diagnose(cfg *config.Config, generated *ast.Config, output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		match := testMessage.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		diagnostic := Diagnostic{Level: match[1], Message: match[2]}
+		if match[3] == ConfigPath {
+			diagnostic.Line, _ = strconv.Atoi(match[4])
+			if chain := locate(generated.Directives, diagnostic.Line, nil); len(chain) > 0 {
+				d := chain[len(chain)-1].directive
+				diagnostic.Directive = strings.TrimSuffix(strings.SplitN(d.String(), "\n", 2)[0], " {")
+				diagnostic.Setting = settingFor(cfg, chain)
+			}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// located is a directive on the path to a line, with the comment that heads
+// its section.
+type located struct {
+	directive *ast.Directive
+	section   string
+}
+
+// locate returns the chain of blocks down to the last directive starting at
+// or before line. nginx reports the line a directive ends on, which is later
+// than its start when long arguments are wrapped.
+func locate(nodes []*ast.Directive, line int, chain []located) []located {
+	var best *located
+	section := ""
+	for _, d := range nodes {
+		switch {
+		case d.IsComment():
+			section = strings.TrimSpace(strings.TrimPrefix(d.Comment, "#"))
+		case !d.IsBlank() && d.Line <= line:
+			best = &located{directive: d, section: section}
+		}
+	}
+	if best == nil {
+		return chain
+	}
+
+	chain = append(chain, *best)
+	if best.directive.IsBlock {
+		return locate(best.directive.Block, line, chain)
+	}
+	return chain
+}
+
+func settingFor(cfg *config.Config, chain []located) string {
+	if len(chain) < 2 {
+		return ""
+	}
+
+	switch chain[0].directive.Name {
+	case "http":
+		return httpSetting(cfg, chain[1:])
+	case "stream":
+		if inSnippet(cfg.Nginx.Snippets.Stream, chain[1].directive) {
+			return "nginx.snippets.stream"
+		}
+		if name := strings.TrimPrefix(chain[1].section, "Stream: "); name != chain[1].section {
+			return "nginx.streams[" + name + "]"
+		}
+	}
+	return ""
+}
+
+func httpSetting(cfg *config.Config, chain []located) string {
+	d := chain[0].directive
+	if inSnippet(cfg.Nginx.Snippets.HTTP, d) {
+		return "nginx.snippets.http"
+	}
+
+	switch d.Name {
+	case "server":
+		return serverSetting(cfg, d, chain[1:])
+	case "upstream":
+		return "nginx.upstreams[" + d.Arg(0) + "]"
+	case "proxy_cache_path":
+		return "nginx.cache"
+	case "set_real_ip_from", "real_ip_header", "real_ip_recursive":
+		return "security.trusted_proxies"
+	case "log_format", "access_log", "error_log":
+		return "logging"
+	}
+
+	section := chain[0].section
+	switch {
+	case strings.HasPrefix(section, "Rate limit policy: "):
+		return "security.rate_limit.policies[" + strings.TrimPrefix(section, "Rate limit policy: ") + "]"
+	case section == "Rate Limiting":
+		return "security.rate_limit"
+	}
+	return ""
+}
+
+// serverScope is the part of keynginx.yaml a server block was generated
+// from.
+type serverScope struct {
+	prefix        string
+	services      []config.ServiceConfig
+	customHeaders map[string]string
+	host          string
+}
+
+func serverSetting(cfg *config.Config, server *ast.Directive, chain []located) string {
+	scope := findServerScope(cfg, server)
+	if len(chain) == 0 {
+		return scope.prefix
+	}
+
+	d, section := chain[0].directive, chain[0].section
+	if inSnippet(cfg.Nginx.Snippets.Server, d) {
+		return "nginx.snippets.server"
+	}
+
+	switch d.Name {
+	case "location":
+		if setting := locationSetting(cfg, scope, d, chain[1:]); setting != "" {
+			return setting
+		}
+	case "listen":
+		switch {
+		case server.Child("ssl_certificate") == nil:
+			return "nginx.http_port"
+		case strings.Contains(" "+strings.Join(d.Args, " ")+" ", " quic "):
+			return "nginx.http3"
+		}
+		return "nginx.https_port"
+	case "server_name":
+		switch {
+		case scope.host != "":
+			return scope.host
+		case scope.prefix == "nginx":
+			return "nginx.server_name"
+		}
+		return scope.prefix + ".server_names"
+	case "ssl_certificate", "ssl_certificate_key":
+		if scope.prefix != "nginx" {
+			return scope.prefix + "." + strings.TrimPrefix(d.Name, "ssl_")
+		}
+		return "ssl"
+	case "add_header":
+		return headerSetting(cfg, scope, d.Arg(0))
+	case "allow", "deny":
+		return "security.access"
+	case "auth_basic", "auth_basic_user_file":
+		return "security.basic_auth"
+	case "limit_req", "limit_conn":
+		return "security.rate_limit"
+	}
+
+	switch {
+	case section == "Redirects":
+		return scope.prefix + ".redirects"
+	case section == "Rewrites":
+		return scope.prefix + ".rewrites"
+	case strings.HasPrefix(section, "Canonical host"):
+		return scope.prefix + ".canonical_host"
+	}
+	return scope.prefix
+}
+
+// findServerScope identifies a server block by its server_name: a site, a
+// host-routed service or the main server.
+func findServerScope(cfg *config.Config, server *ast.Directive) serverScope {
+	var names []string
+	if serverName := server.Child("server_name"); serverName != nil {
+		names = serverName.Args
+	}
+
+	for _, site := range cfg.Nginx.Sites {
+		if len(names) > 0 && names[0] == site.ServerNames[0] {
+			return serverScope{
+				prefix:        "nginx.sites[" + site.Name + "]",
+				services:      site.Services,
+				customHeaders: site.CustomHeaders,
+			}
+		}
+	}
+
+	scope := serverScope{prefix: "nginx", customHeaders: cfg.Nginx.CustomHeaders}
+	for _, service := range cfg.Nginx.Services {
+		host := service.HostName(cfg.Project.Domain)
+		if host == "" || len(names) == 0 || names[0] != host {
+			continue
+		}
+		service.Path = service.LocationPath()
+		scope.services = append(scope.services, service)
+		if scope.host == "" {
+			scope.host = "nginx.services[" + service.Name + "].host"
+		}
+	}
+	if scope.host == "" {
+		for _, service := range cfg.Nginx.Services {
+			if service.HostName(cfg.Project.Domain) == "" {
+				scope.services = append(scope.services, service)
+			}
+		}
+	}
+	return scope
+}
+
+func locationSetting(cfg *config.Config, scope serverScope, location *ast.Directive, chain []located) string {
+	path := strings.Join(location.Args, " ")
+	var service *config.ServiceConfig
+	for i := range scope.services {
+		if strings.Join(scope.services[i].LocationArgs(), " ") == path {
+			service = &scope.services[i]
+			break
+		}
+	}
+	if service == nil {
+		return ""
+	}
+
+	prefix := scope.prefix + ".services[" + service.Name + "]"
+	if len(chain) == 0 {
+		return prefix + ".path"
+	}
+
+	d := chain[0].directive
+	switch {
+	case inSnippet(service.Snippet, d):
+		return prefix + ".snippet"
+	case inSnippet(cfg.Nginx.Snippets.Location, d):
+		return "nginx.snippets.location"
+	}
+
+	switch d.Name {
+	case "proxy_pass", "grpc_pass":
+		if service.Upstream != "" {
+			return prefix + ".upstream"
+		}
+		if service.ProxyPass != "" {
+			return prefix + ".proxy_pass"
+		}
+		return prefix + ".port"
+	case "proxy_connect_timeout", "proxy_read_timeout", "proxy_send_timeout", "proxy_buffering", "proxy_request_buffering", "proxy_http_version",
+		"proxy_next_upstream", "proxy_next_upstream_tries", "proxy_next_upstream_timeout":
+		return prefix + ".proxy." + strings.TrimPrefix(d.Name, "proxy_")
+	case "client_max_body_size":
+		return prefix + ".proxy.max_body_size"
+	case "proxy_set_header":
+		if _, ok := service.Proxy.SetHeaders[d.Arg(0)]; ok {
+			return prefix + ".proxy.set_headers." + d.Arg(0)
+		}
+	case "proxy_hide_header":
+		return prefix + ".proxy.hide_headers"
+	case "add_header":
+		if _, ok := service.Proxy.AddHeaders[d.Arg(0)]; ok {
+			return prefix + ".proxy.add_headers." + d.Arg(0)
+		}
+		if setting := headerSetting(cfg, scope, d.Arg(0)); setting != "security.level" {
+			return setting
+		}
+	case "proxy_cache", "proxy_cache_valid", "proxy_cache_key", "proxy_cache_bypass", "proxy_no_cache":
+		return prefix + ".cache"
+	case "limit_req", "limit_conn":
+		return prefix + ".rate_limit"
+	case "allow", "deny":
+		entries := service.Access.Deny
+		if d.Name == "allow" {
+			entries = service.Access.Allow
+		}
+		if containsString(entries, d.Arg(0)) {
+			return prefix + ".access"
+		}
+		return "security.access"
+	case "auth_basic", "auth_basic_user_file":
+		return prefix + ".basic_auth"
+	case "access_log":
+		return "logging.per_service"
+	case "root", "alias", "index", "try_files", "autoindex", "gzip_static", "brotli_static":
+		return prefix + ".static"
+	case "location":
+		return prefix + ".static.cache_control"
+	case "rewrite":
+		return prefix + ".strip_prefix"
+	}
+	return prefix
+}
+
+// headerSetting finds the setting a response header comes from. Headers
+// that are not configured explicitly belong to the security profile.
+func headerSetting(cfg *config.Config, scope serverScope, name string) string {
+	if _, ok := scope.customHeaders[name]; ok {
+		if scope.prefix == "nginx" || scope.host != "" {
+			return "nginx.custom_headers." + name
+		}
+		return scope.prefix + ".custom_headers." + name
+	}
+	if _, ok := cfg.Security.CustomHeaders[name]; ok {
+		return "security.custom_headers." + name
+	}
+
+	switch name {
+	case "Strict-Transport-Security":
+		return "security.hsts_max_age"
+	case "Content-Security-Policy":
+		return "security.csp_policy"
+	case "Alt-Svc":
+		return "nginx.http3"
+	}
+	return "security.level"
+}
+
+// inSnippet reports whether d is one of the top-level directives of a
+// snippet.
+func inSnippet(snippet string, d *ast.Directive) bool {
+	if snippet == "" {
+		return false
+	}
+	parsed, err := ast.Parse([]byte(snippet))
+	if err != nil {
+		return false
+	}
+	for _, candidate := range parsed.Directives {
+		if candidate.Name == d.Name && strings.Join(candidate.Args, " ") == strings.Join(d.Args, " ") {
+			return true
+		}
+	}
+	return false
+}
+
+// BackendHosts returns the host names nginx resolves when it loads the
+// configuration: proxy_pass and grpc_pass targets and upstream servers.
+// Upstream group names, addresses and targets containing variables are
+// left out.
+func BackendHosts(generated *ast.Config) []string {
+	groups := map[string]bool{}
+	for _, upstream := range generated.Find("upstream") {
+		groups[upstream.Arg(0)] = true
+	}
+
+	hosts := map[string]bool{}
+	generated.Walk(func(d *ast.Directive, parents []*ast.Directive) bool {
+		var target string
+		switch {
+		case d.Name == "proxy_pass" || d.Name == "grpc_pass":
+			target = d.Arg(0)
+			if _, address, ok := strings.Cut(target, "://"); ok {
+				target, _, _ = strings.Cut(address, "/")
+			}
+		case d.Name == "server" && !d.IsBlock && len(parents) > 0 && parents[len(parents)-1].Name == "upstream":
+			target = d.Arg(0)
+		default:
+			return true
+		}
+
+		host := target
+		if h, _, err := net.SplitHostPort(target); err == nil {
+			host = h
+		}
+		if host == "" || groups[host] || host == "localhost" || strings.ContainsAny(host, "$/") || net.ParseIP(host) != nil {
+			return true
+		}
+		hosts[host] = true
+		return true
+	})
+
+	result := make([]string, 0, len(hosts))
+	for host := range hosts {
+		result = append(result, host)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,93 @@
+package nginx
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+var corsHeaders = []string{
+	"Access-Control-Allow-Origin",
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+}
+
+// GetCORSConfig builds one origin map per CORS-enabled service. The mapped
+// variable holds the request origin when it is allowed and is empty otherwise,
+// and nginx skips add_header for empty values.
+func GetCORSConfig(cfg *config.Config) []*ast.Directive {
+	var nodes []*ast.Directive
+	for _, service := range cfg.AllServices() {
+		cors := service.CORS
+		if !cors.Enabled() {
+			continue
+		}
+		if len(nodes) == 0 {
+			nodes = append(nodes,
+				ast.Comment("CORS"),
+				ast.NewBlock("map", "$request_method:$http_access_control_request_method", "$keynginx_cors_preflight").Append(
+					ast.New("default", "0"),
+					ast.New("~^OPTIONS:.+$", "1"),
+				),
+			)
+		}
+
+		fallback := ""
+		if containsString(cors.Origins, "*") {
+			fallback = "*"
+		}
+
+		origins := ast.NewBlock("map", "$http_origin", corsOriginVariable(service))
+		origins.Append(ast.New("default", fallback))
+		for _, origin := range cors.Origins {
+			if origin != "*" {
+				origins.Append(ast.New(config.OriginPattern(origin), "$http_origin"))
+			}
+		}
+		nodes = append(nodes, ast.Blank(), origins)
+	}
+	return nodes
+}
+
+func corsOriginVariable(service config.ServiceConfig) string {
+	return "$keynginx_cors_origin_" + service.CORSVariable()
+}
+
+// corsPreflight answers preflight requests in the rewrite phase, before basic
+// auth or the backend see them.
+func corsPreflight(service config.ServiceConfig) *ast.Directive {
+	cors := service.CORS
+	preflight := ast.NewBlock("if", "($keynginx_cors_preflight)").Append(
+		ast.New("add_header", "Access-Control-Allow-Origin", corsOriginVariable(service), "always"),
+		ast.New("add_header", "Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods(), ", "), "always"),
+		ast.New("add_header", "Access-Control-Allow-Headers", strings.Join(cors.AllowedHeaders(), ", "), "always"),
+	)
+	if cors.Credentials {
+		preflight.Append(ast.New("add_header", "Access-Control-Allow-Credentials", "true", "always"))
+	}
+	return preflight.Append(
+		ast.New("add_header", "Access-Control-Max-Age", strconv.Itoa(cors.MaxAgeOrDefault()), "always"),
+		ast.New("add_header", "Vary", "Origin", "always"),
+		ast.New("return", "204"),
+	)
+}
+
+func corsResponseHeaders(service config.ServiceConfig) map[string]string {
+	cors := service.CORS
+	headers := map[string]string{
+		"Access-Control-Allow-Origin": corsOriginVariable(service),
+		"Vary":                        "Origin",
+	}
+	if cors.Credentials {
+		headers["Access-Control-Allow-Credentials"] = "true"
+	}
+	if len(cors.ExposeHeaders) > 0 {
+		headers["Access-Control-Expose-Headers"] = strings.Join(cors.ExposeHeaders, ", ")
+	}
+	return headers
+}
@@ -3,218 +3,312 @@ package nginx
 import (
 	"bytes"
 	"fmt"
-	"text/template"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+	"github.com/sinhaparth5/keynginx/internal/templates"
 )
 
+const sslPath = "/etc/nginx/ssl"
+
 type Generator struct{}
 
 func NewGenerator() *Generator {
 	return &Generator{}
 }
 
+// siteData feeds a server block. ServerNames and Certificate are the raw
+// values for template overrides; ServerName and SSLConfig are the printed,
+// quoted directives the built-in template uses.
+type siteData struct {
+	Name            string
+	ServerNames     string
+	ServerName      string
+	ContactHost     string
+	Listen          string
+	HTTPPort        int
+	HTTPSPortSuffix string
+	Certificate     string
+	CertificateKey  string
+	SSLConfig       string
+	SecurityHeaders map[string]string
+	CustomHeaders   map[string]string
+	HTTP3Headers    map[string]string
+	AccessConfig    string
+	PublicAccess    string
+	RoutingConfig   string
+	ErrorLocations  string
+	Snippets        config.SnippetsConfig
+	Services        []serviceData
+}
+
+type serviceData struct {
+	config.ServiceConfig
+	Location       string
+	LocationConfig string
+	Snippets       config.SnippetsConfig
+}
+
+// GenerateConfig renders nginx.conf. The template output is parsed and
+// printed again, which rejects structurally broken overrides or snippets and
+// gives the file a canonical layout.
 func (g *Generator) GenerateConfig(cfg *config.Config) (string, error) {
-	tmpl, err := template.New("nginx").Parse(nginxTemplate)
+	parsed, err := g.BuildConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// BuildConfig renders nginx.conf and returns it as a syntax tree that can be
+// queried.
+func (g *Generator) BuildConfig(cfg *config.Config) (*ast.Config, error) {
+	tmpl, err := templates.Load(filepath.Join(cfg.Project.OutputDir, config.TemplatesDir))
 	if err != nil {
-		return "", fmt.Errorf("failed to parse nginx template: %w", err)
+		return nil, err
+	}
+
+	var connectionUpgrade []*ast.Directive
+	if NeedsConnectionUpgradeMap(cfg) {
+		connectionUpgrade = connectionUpgradeMap()
 	}
 
 	data := struct {
 		*config.Config
-		SecurityHeaders map[string]string
-		RateLimitConfig string
-		Timestamp       string
+		Sites         []siteData
+		LoggingConfig string
+		HTTPSections  []string
+		StreamConfig  string
+		Timestamp     string
 	}{
-		Config:          cfg,
-		SecurityHeaders: GetSecurityHeaders(&cfg.Security),
-		RateLimitConfig: GetRateLimitConfig(&cfg.Security.RateLimit),
-		Timestamp:       time.Now().Format("2006-01-02 15:04:05"),
+		Config:        cfg,
+		Sites:         buildSites(cfg),
+		LoggingConfig: ast.Print(GetLoggingConfig(cfg.Logging), httpDepth),
+		HTTPSections: httpSections(
+			connectionUpgrade,
+			GetUpstreamConfig(cfg.Nginx.Upstreams),
+			GetCacheConfig(cfg),
+			GetRealIPConfig(cfg),
+			GetCORSConfig(cfg),
+			GetRateLimitConfig(&cfg.Security.RateLimit),
+		),
+		StreamConfig: ast.Print(GetStreamConfig(cfg), httpDepth),
+		Timestamp:    time.Now().Format("2006-01-02 15:04:05"),
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute nginx template: %w", err)
+	if err := tmpl.ExecuteTemplate(&buf, templates.NginxTemplate, data); err != nil {
+		return nil, fmt.Errorf("failed to execute nginx template: %w", err)
 	}
 
-	return buf.String(), nil
+	parsed, err := ast.Parse(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated nginx configuration is invalid (check template overrides and snippets): %w", err)
+	}
+	return parsed, nil
+}
+
+func buildSites(cfg *config.Config) []siteData {
+	securityHeaders := GetSecurityHeaders(&cfg.Security)
+	http3Headers := GetHTTP3Headers(&cfg.Nginx)
+
+	var pathServices []config.ServiceConfig
+	hostServices := map[string][]config.ServiceConfig{}
+	for _, service := range cfg.Nginx.Services {
+		host := service.HostName(cfg.Project.Domain)
+		if host == "" {
+			pathServices = append(pathServices, service)
+			continue
+		}
+		service.Path = service.LocationPath()
+		hostServices[host] = append(hostServices[host], service)
+	}
+
+	sites := []siteData{{
+		Name:            "default",
+		ServerNames:     cfg.Nginx.ServerName,
+		ServerName:      serverNameDirective(strings.Fields(cfg.Nginx.ServerName)),
+		ContactHost:     firstField(cfg.Nginx.ServerName),
+		Listen:          ast.Print(GetListenConfig(&cfg.Nginx, true), serverDepth),
+		HTTPPort:        cfg.Nginx.HTTPPort,
+		HTTPSPortSuffix: cfg.Nginx.HTTPSPortSuffix(),
+		Certificate:     sslPath + "/certificate.crt",
+		CertificateKey:  sslPath + "/private.key",
+		SSLConfig:       ast.Print(GetSSLConfig(sslPath+"/certificate.crt", sslPath+"/private.key"), serverDepth),
+		SecurityHeaders: securityHeaders,
+		CustomHeaders:   cfg.Nginx.CustomHeaders,
+		HTTP3Headers:    http3Headers,
+		AccessConfig:    ast.Print(GetAccessConfig(&cfg.Security), serverDepth),
+		PublicAccess:    ast.Print(GetPublicAccess(&cfg.Security), locationDepth),
+		RoutingConfig:   ast.Print(GetRoutingConfig(cfg.Nginx.Redirects, cfg.Nginx.Rewrites, cfg.Nginx.CanonicalHost, cfg.Nginx.HTTPSPortSuffix()), serverDepth),
+		ErrorLocations:  ast.Print(GetGRPCErrorLocations(pathServices), serverDepth),
+		Snippets:        cfg.Nginx.Snippets,
+		Services:        buildServices(cfg, &cfg.Security, "", pathServices, mergeHeaders(securityHeaders, cfg.Nginx.CustomHeaders, http3Headers)),
+	}}
+
+	// Host-routed services share the project certificate, which covers
+	// *.domain and any extra hosts added when it was generated.
+	for _, host := range cfg.ServiceHosts() {
+		sites = append(sites, siteData{
+			Name:            host,
+			ServerNames:     host,
+			ServerName:      serverNameDirective([]string{host}),
+			ContactHost:     host,
+			Listen:          ast.Print(GetListenConfig(&cfg.Nginx, false), serverDepth),
+			HTTPPort:        cfg.Nginx.HTTPPort,
+			HTTPSPortSuffix: cfg.Nginx.HTTPSPortSuffix(),
+			Certificate:     sslPath + "/certificate.crt",
+			CertificateKey:  sslPath + "/private.key",
+			SSLConfig:       ast.Print(GetSSLConfig(sslPath+"/certificate.crt", sslPath+"/private.key"), serverDepth),
+			SecurityHeaders: securityHeaders,
+			CustomHeaders:   cfg.Nginx.CustomHeaders,
+			HTTP3Headers:    http3Headers,
+			AccessConfig:    ast.Print(GetAccessConfig(&cfg.Security), serverDepth),
+			PublicAccess:    ast.Print(GetPublicAccess(&cfg.Security), locationDepth),
+			ErrorLocations:  ast.Print(GetGRPCErrorLocations(hostServices[host]), serverDepth),
+			Snippets:        cfg.Nginx.Snippets,
+			Services:        buildServices(cfg, &cfg.Security, "", hostServices[host], mergeHeaders(securityHeaders, cfg.Nginx.CustomHeaders, http3Headers)),
+		})
+	}
+
+	for _, site := range cfg.Nginx.Sites {
+		security := site.Security(cfg.Security)
+		siteHeaders := GetSecurityHeaders(&security)
+		certificate, key := site.CertificateFiles()
+		certificate = path.Join(sslPath, filepath.ToSlash(certificate))
+		key = path.Join(sslPath, filepath.ToSlash(key))
+		sites = append(sites, siteData{
+			Name:            site.Name,
+			ServerNames:     strings.Join(site.ServerNames, " "),
+			ServerName:      serverNameDirective(site.ServerNames),
+			ContactHost:     site.ServerNames[0],
+			Listen:          ast.Print(GetListenConfig(&cfg.Nginx, false), serverDepth),
+			HTTPPort:        cfg.Nginx.HTTPPort,
+			HTTPSPortSuffix: cfg.Nginx.HTTPSPortSuffix(),
+			Certificate:     certificate,
+			CertificateKey:  key,
+			SSLConfig:       ast.Print(GetSSLConfig(certificate, key), serverDepth),
+			SecurityHeaders: siteHeaders,
+			CustomHeaders:   site.CustomHeaders,
+			HTTP3Headers:    http3Headers,
+			AccessConfig:    ast.Print(GetAccessConfig(&security), serverDepth),
+			PublicAccess:    ast.Print(GetPublicAccess(&security), locationDepth),
+			RoutingConfig:   ast.Print(GetRoutingConfig(site.Redirects, site.Rewrites, site.CanonicalHost, cfg.Nginx.HTTPSPortSuffix()), serverDepth),
+			ErrorLocations:  ast.Print(GetGRPCErrorLocations(site.Services), serverDepth),
+			Snippets:        cfg.Nginx.Snippets,
+			Services:        buildServices(cfg, &security, site.Name, site.Services, mergeHeaders(siteHeaders, site.CustomHeaders, http3Headers)),
+		})
+	}
+
+	return sites
+}
+
+// httpSections prints the non-empty http-level sections, which the template
+// separates with one blank line each.
+func httpSections(sections ...[]*ast.Directive) []string {
+	var result []string
+	for _, section := range sections {
+		if len(section) > 0 {
+			result = append(result, ast.Print(section, httpDepth))
+		}
+	}
+	return result
+}
+
+// composeVolumes lists the extra volume entries. Relative sources get a ./
+// prefix so Compose treats them as bind mounts rather than named volumes.
+func composeVolumes(cfg *config.Config) []string {
+	var volumes []string
+	for _, m := range cfg.Mounts() {
+		source := m.Source
+		if !m.Volume && !filepath.IsAbs(source) && !strings.HasPrefix(source, ".") {
+			source = "./" + source
+		}
+		volume := source + ":" + m.Target
+		if m.ReadOnly {
+			volume += ":ro"
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+func namedVolumes(cfg *config.Config) []string {
+	var volumes []string
+	for _, m := range cfg.Mounts() {
+		if m.Volume {
+			volumes = append(volumes, m.Source)
+		}
+	}
+	return volumes
+}
+
+func buildServices(cfg *config.Config, security *config.SecurityConfig, site string, services []config.ServiceConfig, inheritedHeaders map[string]string) []serviceData {
+	result := make([]serviceData, 0, len(services))
+	for _, service := range services {
+		var location []*ast.Directive
+		switch {
+		case service.IsStatic():
+			location = GetStaticConfig(cfg, security, site, service, inheritedHeaders)
+		case service.IsGRPC():
+			location = GetGRPCConfig(cfg, security, site, service, inheritedHeaders)
+		default:
+			location = GetProxyConfig(cfg, security, site, service, inheritedHeaders)
+		}
+
+		result = append(result, serviceData{
+			ServiceConfig:  service,
+			Location:       locationArgs(service.LocationArgs()),
+			LocationConfig: ast.Print(location, locationDepth),
+			Snippets:       cfg.Nginx.Snippets,
+		})
+	}
+	return result
+}
+
+func mergeHeaders(sets ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, set := range sets {
+		for name, value := range set {
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+func firstField(value string) string {
+	if fields := strings.Fields(value); len(fields) > 0 {
+		return fields[0]
+	}
+	return value
 }
 
 func (g *Generator) GenerateDockerCompose(cfg *config.Config) (string, error) {
-	tmpl, err := template.New("docker-compose").Parse(dockerComposeTemplate)
+	tmpl, err := templates.Load(filepath.Join(cfg.Project.OutputDir, config.TemplatesDir))
 	if err != nil {
-		return "", fmt.Errorf("failed to parse docker-compose template: %w", err)
+		return "", err
 	}
 
 	data := struct {
 		*config.Config
-		Timestamp string
+		Volumes      []string
+		NamedVolumes []string
+		Timestamp    string
 	}{
-		Config:    cfg,
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Config:       cfg,
+		Volumes:      composeVolumes(cfg),
+		NamedVolumes: namedVolumes(cfg),
+		Timestamp:    time.Now().Format("2006-01-02 15:04:05"),
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	if err := tmpl.ExecuteTemplate(&buf, templates.ComposeTemplate, data); err != nil {
 		return "", fmt.Errorf("failed to execute docker-compose template: %w", err)
 	}
 
 	return buf.String(), nil
 }
-
-const nginxTemplate = `# KeyNginx Generated Configuration
-# Domain: {{.Project.Domain}}
-# Security Level: {{.Security.Level}}
-# Generated: {{.Timestamp}}
-
-events {
-    worker_connections 1024;
-    multi_accept on;
-    use epoll;
-}
-
-http {
-    include       /etc/nginx/mime.types;
-    default_type  application/octet-stream;
-
-    # Logging Configuration
-    log_format main '$remote_addr - $remote_user [$time_local] "$request" '
-                   '$status $body_bytes_sent "$http_referer" '
-                   '"$http_user_agent" "$http_x_forwarded_for"';
-
-    access_log /var/log/nginx/access.log main;
-    error_log /var/log/nginx/error.log warn;
-
-    # Basic Settings
-    sendfile on;
-    tcp_nopush on;
-    tcp_nodelay on;
-    keepalive_timeout 65;
-    types_hash_max_size 2048;
-    client_max_body_size 16M;
-
-    # Hide server tokens
-    server_tokens off;
-
-    # Gzip Compression
-    gzip on;
-    gzip_vary on;
-    gzip_comp_level 6;
-    gzip_min_length 1024;
-    gzip_proxied any;
-    gzip_types
-        text/plain
-        text/css
-        text/xml
-        text/javascript
-        application/javascript
-        application/json
-        application/xml
-        application/rss+xml
-        application/atom+xml
-        image/svg+xml;
-
-{{.RateLimitConfig}}
-
-    # HTTP to HTTPS redirect
-    server {
-        listen {{.Nginx.HTTPPort}};
-        server_name {{.Nginx.ServerName}};
-        return 301 https://$server_name$request_uri;
-    }
-
-    # HTTPS server
-    server {
-        listen {{.Nginx.HTTPSPort}} ssl http2;
-        server_name {{.Nginx.ServerName}};
-
-        # SSL Configuration
-        ssl_certificate /etc/nginx/ssl/certificate.crt;
-        ssl_certificate_key /etc/nginx/ssl/private.key;
-        ssl_protocols TLSv1.2 TLSv1.3;
-        ssl_ciphers ECDHE-RSA-AES256-GCM-SHA512:DHE-RSA-AES256-GCM-SHA512:ECDHE-RSA-AES256-GCM-SHA384;
-        ssl_prefer_server_ciphers off;
-        ssl_session_cache shared:SSL:10m;
-        ssl_session_timeout 10m;
-
-        # Security Headers{{range $key, $value := .SecurityHeaders}}
-        add_header {{$key}} "{{$value}}" always;{{end}}
-
-        # Custom Headers{{range $key, $value := .Nginx.CustomHeaders}}
-        add_header {{$key}} "{{$value}}" always;{{end}}
-
-{{if .Nginx.Services}}{{range .Nginx.Services}}
-        # Service: {{.Name}}
-        location {{.Path}} {
-            proxy_pass {{.ProxyPass}};
-            proxy_set_header Host $host;
-            proxy_set_header X-Real-IP $remote_addr;
-            proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
-            proxy_set_header X-Forwarded-Proto $scheme;
-            proxy_set_header X-Forwarded-Host $server_name;
-
-            # Remove server identification
-            proxy_hide_header X-Powered-By;
-            proxy_hide_header Server;
-        }
-{{end}}{{else}}
-        # Default location
-        location / {
-            root /usr/share/nginx/html;
-            index index.html index.htm;
-        }
-{{end}}
-        # Health check endpoint
-        location /health {
-            access_log off;
-            return 200 "healthy\n";
-            add_header Content-Type text/plain;
-        }
-
-        # Security.txt endpoint
-        location /.well-known/security.txt {
-            return 200 "# KeyNginx Generated Security Policy\nContact: mailto:admin@{{.Nginx.ServerName}}\n";
-            add_header Content-Type text/plain;
-        }
-    }
-}
-`
-
-const dockerComposeTemplate = `# KeyNginx Generated Docker Compose
-# Domain: {{.Project.Domain}}
-# Generated: {{.Timestamp}}
-version: '{{.Docker.ComposeVersion}}'
-
-services:
-  nginx:
-    image: {{.Docker.NginxImage}}
-    container_name: keynginx-{{.Project.Domain}}
-    ports:
-      - "{{.Nginx.HTTPSPort}}:443"
-      - "{{.Nginx.HTTPPort}}:80"
-    volumes:
-      - ./nginx.conf:/etc/nginx/nginx.conf:ro
-      - ./ssl:/etc/nginx/ssl:ro
-      - ./logs:/var/log/nginx
-    restart: unless-stopped
-    networks:
-      - {{.Docker.NetworkName}}
-
-{{if .Nginx.Services}}{{range .Nginx.Services}}
-  # {{.Name}}:
-  #   build: ./{{.Name}}
-  #   ports:
-  #     - "{{.Port}}:{{.Port}}"
-  #   networks:
-  #     - {{$.Docker.NetworkName}}
-  #   # Uncomment and configure as needed
-
-{{end}}{{end}}
-networks:
-  {{.Docker.NetworkName}}:
-    driver: bridge
-
-volumes:
-  nginx-logs:
-`
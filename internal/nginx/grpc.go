@@ -0,0 +1,128 @@
+package nginx
+
+import (
+	"strconv"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// grpcErrors maps the HTTP errors nginx itself produces (upstream down, rate
+// limited, denied) to gRPC status codes, so clients see a proper grpc-status
+// instead of an HTML error page.
+var grpcErrors = []struct {
+	name     string
+	statuses []string
+	code     int
+	message  string
+}{
+	{"unauthenticated", []string{"401"}, 16, "Unauthenticated"},
+	{"permission_denied", []string{"403"}, 7, "Permission denied"},
+	{"unimplemented", []string{"404"}, 12, "Unimplemented"},
+	{"resource_exhausted", []string{"429"}, 8, "Resource exhausted"},
+	{"unavailable", []string{"502", "503", "504"}, 14, "Unavailable"},
+}
+
+func GetGRPCConfig(cfg *config.Config, security *config.SecurityConfig, site string, service config.ServiceConfig, inheritedHeaders map[string]string) []*ast.Directive {
+	proxy := service.Proxy
+	var nodes []*ast.Directive
+	add := func(name string, args ...string) {
+		nodes = append(nodes, ast.New(name, args...))
+	}
+
+	add("grpc_pass", service.ProxyTarget())
+	if service.Protocol == "grpcs" {
+		add("grpc_ssl_server_name", "on")
+	}
+
+	add("grpc_set_header", "X-Real-IP", "$remote_addr")
+	add("grpc_set_header", "X-Forwarded-For", "$proxy_add_x_forwarded_for")
+	add("grpc_set_header", "X-Forwarded-Proto", "$scheme")
+	add("grpc_set_header", "X-Forwarded-Host", "$server_name")
+	for _, name := range sortedKeys(proxy.SetHeaders) {
+		add("grpc_set_header", name, proxy.SetHeaders[name])
+	}
+
+	var tuning []*ast.Directive
+	tune := func(name string, args ...string) {
+		tuning = append(tuning, ast.New(name, args...))
+	}
+	if proxy.ConnectTimeout != "" {
+		tune("grpc_connect_timeout", proxy.ConnectTimeout)
+	}
+	if proxy.ReadTimeout != "" {
+		tune("grpc_read_timeout", proxy.ReadTimeout)
+	}
+	if proxy.SendTimeout != "" {
+		tune("grpc_send_timeout", proxy.SendTimeout)
+	}
+	if proxy.MaxBodySize != "" {
+		tune("client_max_body_size", proxy.MaxBodySize)
+	}
+	if len(proxy.NextUpstream) > 0 {
+		tune("grpc_next_upstream", proxy.NextUpstream...)
+	}
+	if proxy.NextUpstreamTries > 0 {
+		tune("grpc_next_upstream_tries", strconv.Itoa(proxy.NextUpstreamTries))
+	}
+	if proxy.NextUpstreamTimeout != "" {
+		tune("grpc_next_upstream_timeout", proxy.NextUpstreamTimeout)
+	}
+	nodes = appendSection(nodes, "gRPC tuning", tuning)
+
+	var errorPages []*ast.Directive
+	for _, grpcError := range grpcErrors {
+		args := append(append([]string{}, grpcError.statuses...), "=", "@keynginx_grpc_"+grpcError.name)
+		errorPages = append(errorPages, ast.New("error_page", args...))
+	}
+	nodes = appendSection(nodes, "Map nginx errors to gRPC status codes", errorPages)
+
+	hide := []*ast.Directive{
+		ast.New("grpc_hide_header", "X-Powered-By"),
+		ast.New("grpc_hide_header", "Server"),
+	}
+	for _, name := range proxy.HideHeaders {
+		hide = append(hide, ast.New("grpc_hide_header", name))
+	}
+	nodes = appendSection(nodes, "Remove server identification", hide)
+
+	nodes = append(nodes, accessSections(cfg, security, site, service)...)
+
+	if len(proxy.AddHeaders) > 0 {
+		nodes = appendSection(nodes, "Response headers (server-level headers repeated, see add_header inheritance)",
+			addHeaders(mergeHeaders(inheritedHeaders, proxy.AddHeaders)))
+	}
+
+	return nodes
+}
+
+// GetGRPCErrorLocations builds the named locations used by the gRPC
+// error_page mappings. They skip auth and allow lists so the error itself is
+// not rejected again.
+func GetGRPCErrorLocations(services []config.ServiceConfig) []*ast.Directive {
+	used := false
+	for _, service := range services {
+		if service.IsGRPC() {
+			used = true
+		}
+	}
+	if !used {
+		return nil
+	}
+
+	nodes := []*ast.Directive{ast.Comment("gRPC error responses")}
+	for i, grpcError := range grpcErrors {
+		if i > 0 {
+			nodes = append(nodes, ast.Blank())
+		}
+		nodes = append(nodes, ast.NewBlock("location", "@keynginx_grpc_"+grpcError.name).Append(
+			ast.New("auth_basic", "off"),
+			ast.New("allow", "all"),
+			ast.New("default_type", "application/grpc"),
+			ast.New("add_header", "grpc-status", strconv.Itoa(grpcError.code), "always"),
+			ast.New("add_header", "grpc-message", grpcError.message, "always"),
+			ast.New("return", "204"),
+		))
+	}
+	return nodes
+}
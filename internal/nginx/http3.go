@@ -0,0 +1,41 @@
+package nginx
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// GetListenConfig builds the listen directives of an HTTPS server. reuseport
+// may only appear once per address, so only the first server sets it on the
+// shared QUIC socket.
+func GetListenConfig(nginx *config.NginxConfig, first bool) []*ast.Directive {
+	port := strconv.Itoa(nginx.HTTPSPort)
+	if !nginx.HTTP3 {
+		return []*ast.Directive{ast.New("listen", port, "ssl", "http2")}
+	}
+
+	quic := ast.New("listen", port, "quic")
+	if first {
+		quic.Args = append(quic.Args, "reuseport")
+	}
+	return []*ast.Directive{
+		ast.New("listen", port, "ssl"),
+		quic,
+		ast.New("http2", "on"),
+		ast.New("http3", "on"),
+	}
+}
+
+// GetHTTP3Headers returns the Alt-Svc header that tells browsers the HTTP/3
+// endpoint exists.
+func GetHTTP3Headers(nginx *config.NginxConfig) map[string]string {
+	if !nginx.HTTP3 {
+		return nil
+	}
+	return map[string]string{
+		"Alt-Svc": fmt.Sprintf(`h3=":%d"; ma=86400`, nginx.HTTPSPort),
+	}
+}
@@ -0,0 +1,161 @@
+package nginx
+
+import (
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+const logDir = "/var/log/nginx"
+
+// logField is a key of the JSON presets. Numeric values are written without
+// quotes; variables that may be "-" or a list, such as upstream timings when
+// a request was retried, stay strings.
+type logField struct {
+	key     string
+	value   string
+	numeric bool
+}
+
+var jsonLogFields = []logField{
+	{"time", "$time_iso8601", false},
+	{"request_id", "$request_id", false},
+	{"remote_addr", "$remote_addr", false},
+	{"remote_user", "$remote_user", false},
+	{"host", "$host", false},
+	{"method", "$request_method", false},
+	{"uri", "$request_uri", false},
+	{"protocol", "$server_protocol", false},
+	{"status", "$status", true},
+	{"body_bytes_sent", "$body_bytes_sent", true},
+	{"request_time", "$request_time", true},
+	{"referer", "$http_referer", false},
+	{"user_agent", "$http_user_agent", false},
+	{"x_forwarded_for", "$http_x_forwarded_for", false},
+	{"upstream_addr", "$upstream_addr", false},
+	{"upstream_status", "$upstream_status", false},
+	{"upstream_connect_time", "$upstream_connect_time", false},
+	{"upstream_header_time", "$upstream_header_time", false},
+	{"upstream_response_time", "$upstream_response_time", false},
+	{"cache_status", "$upstream_cache_status", false},
+	{"ssl_protocol", "$ssl_protocol", false},
+	{"ssl_cipher", "$ssl_cipher", false},
+}
+
+// ecsLogFields follow the Elastic Common Schema, with nginx-specific values
+// under nginx.*. Dotted keys are expanded into objects by Elasticsearch.
+var ecsLogFields = []logField{
+	{"@timestamp", "$time_iso8601", false},
+	{"ecs.version", "8.11.0", false},
+	{"event.dataset", "nginx.access", false},
+	{"http.request.id", "$request_id", false},
+	{"source.address", "$remote_addr", false},
+	{"user.name", "$remote_user", false},
+	{"url.domain", "$host", false},
+	{"http.request.method", "$request_method", false},
+	{"url.original", "$request_uri", false},
+	{"http.version", "$server_protocol", false},
+	{"http.response.status_code", "$status", true},
+	{"http.response.body.bytes", "$body_bytes_sent", true},
+	{"http.request.referrer", "$http_referer", false},
+	{"user_agent.original", "$http_user_agent", false},
+	{"tls.version", "$ssl_protocol", false},
+	{"tls.cipher", "$ssl_cipher", false},
+	{"nginx.request_time", "$request_time", true},
+	{"nginx.x_forwarded_for", "$http_x_forwarded_for", false},
+	{"nginx.upstream.address", "$upstream_addr", false},
+	{"nginx.upstream.status", "$upstream_status", false},
+	{"nginx.upstream.connect_time", "$upstream_connect_time", false},
+	{"nginx.upstream.header_time", "$upstream_header_time", false},
+	{"nginx.upstream.response_time", "$upstream_response_time", false},
+	{"nginx.cache_status", "$upstream_cache_status", false},
+}
+
+// GetLoggingConfig builds the http-level log_format, access_log and
+// error_log directives.
+func GetLoggingConfig(logging config.LoggingConfig) []*ast.Directive {
+	var nodes []*ast.Directive
+	if logging.AccessEnabled() {
+		nodes = append(nodes,
+			logFormat(logging),
+			ast.Blank(),
+			ast.New("access_log", logDir+"/access.log", logFormatName(logging)),
+		)
+	} else {
+		nodes = append(nodes, ast.New("access_log", "off"))
+	}
+	return append(nodes, ast.New("error_log", logDir+"/error.log", logging.ErrorLevelOrDefault()))
+}
+
+// serviceLogDirectives writes a service's requests to its own file as well.
+// An access_log in a location replaces the inherited ones, so the shared log
+// is repeated. Services of a site log to <site>.<service>.access.log, as
+// sites may reuse service names.
+func serviceLogDirectives(cfg *config.Config, site string, service config.ServiceConfig) []*ast.Directive {
+	if !cfg.Logging.PerService {
+		return nil
+	}
+	name := service.Name
+	if site != "" {
+		name = site + "." + name
+	}
+	format := logFormatName(cfg.Logging)
+	return []*ast.Directive{
+		ast.New("access_log", logDir+"/access.log", format),
+		ast.New("access_log", logDir+"/"+name+".access.log", format),
+	}
+}
+
+// logFormatName is the log_format a preset defines. The combined preset is
+// named main, as nginx predefines combined.
+func logFormatName(logging config.LoggingConfig) string {
+	if format := logging.FormatOrDefault(); format != "combined" {
+		return format
+	}
+	return "main"
+}
+
+func logFormat(logging config.LoggingConfig) *ast.Directive {
+	name := logFormatName(logging)
+	switch logging.FormatOrDefault() {
+	case "json":
+		return jsonLogFormat(name, jsonLogFields)
+	case "ecs":
+		return jsonLogFormat(name, ecsLogFields)
+	case "custom":
+		if strings.HasPrefix(strings.TrimSpace(logging.Custom), "{") {
+			return ast.New("log_format", name, "escape=json", logging.Custom)
+		}
+		return ast.New("log_format", name, logging.Custom)
+	}
+	return ast.New("log_format", name,
+		`$remote_addr - $remote_user [$time_local] "$request" `,
+		`$status $body_bytes_sent "$http_referer" `,
+		`"$http_user_agent" "$http_x_forwarded_for"`,
+	)
+}
+
+// jsonLogFormat writes one field per argument, so the printed format lists
+// a field per line.
+func jsonLogFormat(name string, fields []logField) *ast.Directive {
+	args := []string{name, "escape=json"}
+	for i, field := range fields {
+		value := `"` + field.value + `"`
+		if field.numeric {
+			value = field.value
+		}
+
+		arg := `"` + field.key + `":` + value
+		if i == 0 {
+			arg = "{" + arg
+		}
+		if i == len(fields)-1 {
+			arg += "}"
+		} else {
+			arg += ","
+		}
+		args = append(args, arg)
+	}
+	return ast.New("log_format", args...)
+}
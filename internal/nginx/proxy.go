@@ -0,0 +1,205 @@
+package nginx
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// Nesting depths of the generated sections, used when printing them into the
+// template.
+const (
+	httpDepth     = 1
+	serverDepth   = 2
+	locationDepth = 3
+)
+
+func connectionUpgradeMap() []*ast.Directive {
+	return []*ast.Directive{
+		ast.Comment("WebSocket connection upgrade"),
+		ast.NewBlock("map", "$http_upgrade", "$connection_upgrade").Append(
+			ast.New("default", "upgrade"),
+			ast.New("", ""),
+		),
+	}
+}
+
+// GetProxyConfig builds the body of a service location. inheritedHeaders are
+// the server-level add_header values; nginx drops them in any location that
+// declares its own add_header, so they are repeated when the service adds
+// response headers.
+func GetProxyConfig(cfg *config.Config, security *config.SecurityConfig, site string, service config.ServiceConfig, inheritedHeaders map[string]string) []*ast.Directive {
+	proxy := service.Proxy
+	keepalive := false
+	if upstream := cfg.Upstream(service.Upstream); upstream != nil {
+		keepalive = upstream.Keepalive > 0
+	}
+
+	var nodes []*ast.Directive
+	add := func(name string, args ...string) {
+		nodes = append(nodes, ast.New(name, args...))
+	}
+
+	if rewrite := stripPrefixRewrite(service); rewrite != nil {
+		nodes = append(nodes, rewrite)
+	}
+	add("proxy_pass", service.ProxyTarget())
+
+	httpVersion := proxy.HTTPVersion
+	if httpVersion == "" && (service.WebSocket || service.SSE || keepalive) {
+		httpVersion = "1.1"
+	}
+	if httpVersion != "" {
+		add("proxy_http_version", httpVersion)
+	}
+
+	if service.WebSocket {
+		add("proxy_set_header", "Upgrade", "$http_upgrade")
+		add("proxy_set_header", "Connection", "$connection_upgrade")
+	} else if keepalive || service.SSE {
+		add("proxy_set_header", "Connection", "")
+	}
+
+	add("proxy_set_header", "Host", "$host")
+	add("proxy_set_header", "X-Real-IP", "$remote_addr")
+	add("proxy_set_header", "X-Forwarded-For", "$proxy_add_x_forwarded_for")
+	add("proxy_set_header", "X-Forwarded-Proto", "$scheme")
+	add("proxy_set_header", "X-Forwarded-Host", "$server_name")
+	for _, name := range sortedKeys(proxy.SetHeaders) {
+		add("proxy_set_header", name, proxy.SetHeaders[name])
+	}
+
+	readTimeout := proxy.ReadTimeout
+	sendTimeout := proxy.SendTimeout
+	if service.WebSocket || service.SSE {
+		// Long-lived connections are closed after the default 60s of silence.
+		if readTimeout == "" {
+			readTimeout = "3600s"
+		}
+		if sendTimeout == "" {
+			sendTimeout = "3600s"
+		}
+	}
+
+	var tuning []*ast.Directive
+	tune := func(name string, args ...string) {
+		tuning = append(tuning, ast.New(name, args...))
+	}
+	if proxy.ConnectTimeout != "" {
+		tune("proxy_connect_timeout", proxy.ConnectTimeout)
+	}
+	if readTimeout != "" {
+		tune("proxy_read_timeout", readTimeout)
+	}
+	if sendTimeout != "" {
+		tune("proxy_send_timeout", sendTimeout)
+	}
+
+	buffering := proxy.Buffering
+	if service.SSE && buffering == nil {
+		off := false
+		buffering = &off
+	}
+	if buffering != nil {
+		tune("proxy_buffering", onOff(*buffering))
+	}
+	if proxy.RequestBuffering != nil {
+		tune("proxy_request_buffering", onOff(*proxy.RequestBuffering))
+	}
+	if service.SSE {
+		tune("proxy_cache", "off")
+	}
+	if proxy.MaxBodySize != "" {
+		tune("client_max_body_size", proxy.MaxBodySize)
+	}
+	if len(proxy.NextUpstream) > 0 {
+		tune("proxy_next_upstream", proxy.NextUpstream...)
+	}
+	if proxy.NextUpstreamTries > 0 {
+		tune("proxy_next_upstream_tries", strconv.Itoa(proxy.NextUpstreamTries))
+	}
+	if proxy.NextUpstreamTimeout != "" {
+		tune("proxy_next_upstream_timeout", proxy.NextUpstreamTimeout)
+	}
+	nodes = appendSection(nodes, "Proxy tuning", tuning)
+
+	hide := []*ast.Directive{
+		ast.New("proxy_hide_header", "X-Powered-By"),
+		ast.New("proxy_hide_header", "Server"),
+	}
+	for _, name := range proxy.HideHeaders {
+		hide = append(hide, ast.New("proxy_hide_header", name))
+	}
+	if service.CORS.Enabled() {
+		// CORS headers come from nginx only, never duplicated by the backend.
+		for _, name := range corsHeaders {
+			hide = append(hide, ast.New("proxy_hide_header", name))
+		}
+	}
+	nodes = appendSection(nodes, "Remove server identification", hide)
+
+	nodes = append(nodes, accessSections(cfg, security, site, service)...)
+
+	responseHeaders := proxy.AddHeaders
+	if service.CORS.Enabled() {
+		nodes = appendSection(nodes, "CORS preflight", []*ast.Directive{corsPreflight(service)})
+		responseHeaders = mergeHeaders(responseHeaders, corsResponseHeaders(service))
+	}
+	if cache, ok := cfg.ServiceCache(service); ok {
+		nodes = appendSection(nodes, "Response caching", cacheDirectives(cache))
+		responseHeaders = mergeHeaders(responseHeaders, map[string]string{"X-Cache-Status": "$upstream_cache_status"})
+	}
+
+	if len(responseHeaders) > 0 {
+		nodes = appendSection(nodes, "Response headers (server-level headers repeated, see add_header inheritance)",
+			addHeaders(mergeHeaders(inheritedHeaders, responseHeaders)))
+	}
+
+	return nodes
+}
+
+func NeedsConnectionUpgradeMap(cfg *config.Config) bool {
+	for _, service := range cfg.AllServices() {
+		if service.WebSocket {
+			return true
+		}
+	}
+	return false
+}
+
+// appendSection adds directives under a comment, separated from what comes
+// before by a blank line. Empty sections are left out.
+func appendSection(nodes []*ast.Directive, comment string, section []*ast.Directive) []*ast.Directive {
+	if len(section) == 0 {
+		return nodes
+	}
+	nodes = append(nodes, ast.Blank(), ast.Comment(comment))
+	return append(nodes, section...)
+}
+
+// addHeaders renders response headers in name order, sent for every status.
+func addHeaders(headers map[string]string) []*ast.Directive {
+	var nodes []*ast.Directive
+	for _, name := range sortedKeys(headers) {
+		nodes = append(nodes, ast.New("add_header", name, headers[name], "always"))
+	}
+	return nodes
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
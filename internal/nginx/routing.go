@@ -0,0 +1,79 @@
+package nginx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// GetRoutingConfig builds the server-level canonical host redirect, redirect
+// locations and rewrites. portSuffix keeps non-standard HTTPS ports in
+// absolute redirects.
+func GetRoutingConfig(redirects []config.RedirectRule, rewrites []config.RewriteRule, canonical, portSuffix string) []*ast.Directive {
+	var nodes []*ast.Directive
+
+	switch canonical {
+	case "www":
+		nodes = append(nodes, ast.Comment("Canonical host: www"),
+			ast.NewBlock("if", "($host", "!~*", `^www\.)`).Append(
+				ast.New("return", "301", fmt.Sprintf("https://www.$host%s$request_uri", portSuffix)),
+			))
+	case "non-www":
+		nodes = append(nodes, ast.Comment("Canonical host: non-www"),
+			ast.NewBlock("if", "($host", "~*", `^www\.(.+)$)`).Append(
+				ast.New("return", "301", fmt.Sprintf("https://$1%s$request_uri", portSuffix)),
+			))
+	}
+
+	if len(rewrites) > 0 {
+		if len(nodes) > 0 {
+			nodes = append(nodes, ast.Blank())
+		}
+		nodes = append(nodes, ast.Comment("Rewrites"))
+		for _, rewrite := range rewrites {
+			nodes = append(nodes, ast.New("rewrite", rewrite.From, rewrite.To, rewrite.FlagOrDefault()))
+		}
+	}
+
+	for i, redirect := range redirects {
+		if i == 0 {
+			if len(nodes) > 0 {
+				nodes = append(nodes, ast.Blank())
+			}
+			nodes = append(nodes, ast.Comment("Redirects"))
+		}
+		location, target := redirectLocation(redirect)
+		if redirect.KeepsQuery() && !strings.Contains(target, "?") {
+			target += "$is_args$args"
+		}
+		nodes = append(nodes, ast.NewBlock("location", location...).Append(
+			ast.New("return", strconv.Itoa(redirect.StatusCode()), target),
+		))
+	}
+
+	return nodes
+}
+
+func redirectLocation(redirect config.RedirectRule) ([]string, string) {
+	switch redirect.MatchType() {
+	case "prefix":
+		return []string{"~", fmt.Sprintf("^%s(.*)$", regexp.QuoteMeta(redirect.From))}, redirect.To + "$1"
+	case "regex":
+		return []string{"~", redirect.From}, redirect.To
+	}
+	return []string{"=", redirect.From}, redirect.To
+}
+
+// stripPrefixRewrite removes the location path before proxying, so a service
+// at /api receives /api/users as /users.
+func stripPrefixRewrite(service config.ServiceConfig) *ast.Directive {
+	prefix := strings.TrimSuffix(service.LocationPath(), "/")
+	if !service.StripPrefix || prefix == "" {
+		return nil
+	}
+	return ast.New("rewrite", fmt.Sprintf("^%s/?(.*)$", regexp.QuoteMeta(prefix)), "/$1", "break")
+}
@@ -2,7 +2,10 @@ package nginx
 
 import (
 	"fmt"
+	"strconv"
+
 	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
 )
 
 type SecurityProfile struct {
@@ -57,12 +60,108 @@ func GetSecurityHeaders(cfg *config.SecurityConfig) map[string]string {
 	return headers
 }
 
-func GetRateLimitConfig(cfg *config.RateLimitConfig) string {
-	if !cfg.Enabled {
-		return ""
+func GetRateLimitConfig(cfg *config.RateLimitConfig) []*ast.Directive {
+	if !cfg.Enabled && len(cfg.Policies) == 0 {
+		return nil
+	}
+
+	var nodes []*ast.Directive
+	add := func(name string, args ...string) {
+		nodes = append(nodes, ast.New(name, args...))
+	}
+
+	nodes = append(nodes, ast.Comment("Rate Limiting"))
+	if cfg.Status != 0 {
+		add("limit_req_status", strconv.Itoa(cfg.Status))
+		add("limit_conn_status", strconv.Itoa(cfg.Status))
+	}
+	if cfg.DryRun {
+		add("limit_req_dry_run", "on")
+		add("limit_conn_dry_run", "on")
+	}
+
+	if len(cfg.Allowlist) > 0 {
+		// Allowlisted clients get an empty key, which nginx never limits.
+		exempt := ast.NewBlock("geo", "$keynginx_rate_limit_exempt").Append(ast.New("default", "0"))
+		for _, entry := range cfg.Allowlist {
+			exempt.Append(ast.New(entry, "1"))
+		}
+		nodes = append(nodes, ast.Blank(), exempt)
+	}
+
+	if cfg.Enabled {
+		if len(cfg.Allowlist) > 0 {
+			nodes = append(nodes, ast.Blank())
+		}
+		key := rateLimitKey(cfg, "$binary_remote_addr", "$keynginx_rate_limit_key", &nodes)
+		add("limit_req_zone", key, "zone=keynginx:10m", fmt.Sprintf("rate=%dr/m", cfg.RequestsPerMinute))
+		add("limit_req", "zone=keynginx", fmt.Sprintf("burst=%d", cfg.BurstSize), "nodelay")
+	}
+
+	for _, policy := range cfg.Policies {
+		nodes = append(nodes, ast.Blank(), ast.Comment("Rate limit policy: "+policy.Name))
+		key := rateLimitKey(cfg, policy.KeyVariable(), "$keynginx_rate_limit_key_"+policy.Name, &nodes)
+		size := policy.Size
+		if size == "" {
+			size = "10m"
+		}
+		if policy.Rate != "" {
+			add("limit_req_zone", key, fmt.Sprintf("zone=keynginx_req_%s:%s", policy.Name, size), "rate="+policy.Rate)
+		}
+		if policy.Connections > 0 {
+			add("limit_conn_zone", key, fmt.Sprintf("zone=keynginx_conn_%s:%s", policy.Name, size))
+		}
+	}
+
+	return nodes
+}
+
+// rateLimitKey returns the variable a zone is keyed on, mapping allowlisted
+// clients to an empty key when an allowlist is configured.
+func rateLimitKey(cfg *config.RateLimitConfig, key, mapped string, nodes *[]*ast.Directive) string {
+	if len(cfg.Allowlist) == 0 {
+		return key
+	}
+	*nodes = append(*nodes, ast.NewBlock("map", "$keynginx_rate_limit_exempt", mapped).Append(
+		ast.New("0", key),
+		ast.New("1", ""),
+	))
+	return mapped
+}
+
+// rateLimitDirectives builds the limits for a service location. A location
+// with its own limit_req no longer inherits the http-level one, so the global
+// limit is repeated.
+func rateLimitDirectives(cfg *config.Config, service config.ServiceConfig) []*ast.Directive {
+	if len(service.RateLimit) == 0 {
+		return nil
+	}
+
+	rateLimit := &cfg.Security.RateLimit
+	var nodes []*ast.Directive
+	for _, name := range service.RateLimit {
+		policy := rateLimit.Policy(name)
+		if policy == nil || policy.Rate == "" {
+			continue
+		}
+		args := []string{"zone=keynginx_req_" + policy.Name}
+		if policy.Burst > 0 {
+			args = append(args, fmt.Sprintf("burst=%d", policy.Burst))
+		}
+		if !policy.Delay {
+			args = append(args, "nodelay")
+		}
+		nodes = append(nodes, ast.New("limit_req", args...))
+	}
+	if len(nodes) > 0 && rateLimit.Enabled {
+		nodes = append(nodes, ast.New("limit_req", "zone=keynginx", fmt.Sprintf("burst=%d", rateLimit.BurstSize), "nodelay"))
+	}
+
+	for _, name := range service.RateLimit {
+		if policy := rateLimit.Policy(name); policy != nil && policy.Connections > 0 {
+			nodes = append(nodes, ast.New("limit_conn", "keynginx_conn_"+policy.Name, strconv.Itoa(policy.Connections)))
+		}
 	}
 
-	return fmt.Sprintf(`    # Rate Limiting
-    limit_req_zone $binary_remote_addr zone=keynginx:10m rate=%dr/m;
-    limit_req zone=keynginx burst=%d nodelay;`, cfg.RequestsPerMinute, cfg.BurstSize)
+	return nodes
 }
@@ -0,0 +1,34 @@
+package nginx
+
+import (
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// GetSSLConfig builds the TLS settings of an HTTPS server.
+func GetSSLConfig(certificate, key string) []*ast.Directive {
+	return []*ast.Directive{
+		ast.New("ssl_certificate", certificate),
+		ast.New("ssl_certificate_key", key),
+		ast.New("ssl_protocols", "TLSv1.2", "TLSv1.3"),
+		ast.New("ssl_ciphers", "ECDHE-RSA-AES256-GCM-SHA512:DHE-RSA-AES256-GCM-SHA512:ECDHE-RSA-AES256-GCM-SHA384"),
+		ast.New("ssl_prefer_server_ciphers", "off"),
+		ast.New("ssl_session_cache", "shared:SSL:10m"),
+		ast.New("ssl_session_timeout", "10m"),
+	}
+}
+
+// serverNameDirective lists the names matched by a server block.
+func serverNameDirective(names []string) string {
+	return ast.Print([]*ast.Directive{ast.New("server_name", names...)}, serverDepth)
+}
+
+// locationArgs renders the arguments of a service's location block.
+func locationArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ast.Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,71 @@
+package nginx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// GetStaticConfig builds the body of a static or spa service location. The
+// service directory is mounted below its document root at the location path,
+// so plain root/try_files work without alias.
+func GetStaticConfig(cfg *config.Config, security *config.SecurityConfig, site string, service config.ServiceConfig, inheritedHeaders map[string]string) []*ast.Directive {
+	static := service.Static
+	var nodes []*ast.Directive
+	add := func(name string, args ...string) {
+		nodes = append(nodes, ast.New(name, args...))
+	}
+
+	add("root", service.DocumentRoot())
+	add("index", service.IndexFile())
+	if static.Autoindex {
+		add("autoindex", "on")
+	}
+	for _, encoding := range static.Precompressed {
+		switch encoding {
+		case "gzip":
+			add("gzip_static", "on")
+		case "br":
+			add("brotli_static", "on")
+		}
+	}
+
+	if service.Type == "spa" {
+		// Unknown routes fall back to the app shell for client-side routing.
+		path := service.LocationPath()
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		add("try_files", "$uri", "$uri/", path+service.IndexFile())
+	} else {
+		add("try_files", "$uri", "$uri/", "=404")
+	}
+
+	nodes = append(nodes, accessSections(cfg, security, site, service)...)
+
+	var cacheControl []*ast.Directive
+	for i, extensions := range sortedKeys(static.CacheControl) {
+		if i > 0 {
+			cacheControl = append(cacheControl, ast.Blank())
+		}
+		location := ast.NewBlock("location", "~*", fmt.Sprintf(`\.(%s)$`, extensionPattern(extensions)))
+		location.Append(ast.New("add_header", "Cache-Control", static.CacheControl[extensions], "always"))
+		location.Append(addHeaders(inheritedHeaders)...)
+		cacheControl = append(cacheControl, location)
+	}
+	nodes = appendSection(nodes, "Cache-Control per file type (server-level headers repeated, see add_header inheritance)", cacheControl)
+
+	return nodes
+}
+
+func extensionPattern(extensions string) string {
+	var parts []string
+	for _, ext := range strings.Split(extensions, ",") {
+		if ext = strings.TrimSpace(ext); ext != "" {
+			parts = append(parts, ext)
+		}
+	}
+	return strings.Join(parts, "|")
+}
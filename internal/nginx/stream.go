@@ -0,0 +1,94 @@
+package nginx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+// GetStreamConfig builds the contents of the top-level stream block, one
+// server per configured stream.
+func GetStreamConfig(cfg *config.Config) []*ast.Directive {
+	var nodes []*ast.Directive
+	for i, stream := range cfg.Nginx.Streams {
+		if i > 0 {
+			nodes = append(nodes, ast.Blank())
+		}
+		nodes = append(nodes, streamServer(stream)...)
+	}
+	return nodes
+}
+
+func streamServer(stream config.StreamConfig) []*ast.Directive {
+	nodes := []*ast.Directive{ast.Comment("Stream: " + stream.Name)}
+
+	backend := stream.Backend()
+	if len(stream.SNIRoutes) > 0 {
+		// Routes resolve to upstream groups so host names are looked up once
+		// at startup instead of needing a resolver for variable proxy_pass.
+		variable := "$keynginx_stream_" + streamIdentifier(stream.Name)
+		upstreams := map[string]string{}
+		targets := append(mapValues(stream.SNIRoutes), backend)
+		for _, target := range targets {
+			if _, ok := upstreams[target]; ok {
+				continue
+			}
+			upstreams[target] = fmt.Sprintf("keynginx_stream_%s_%d", streamIdentifier(stream.Name), len(upstreams)+1)
+			nodes = append(nodes, ast.NewBlock("upstream", upstreams[target]).Append(ast.New("server", target)))
+		}
+
+		routes := ast.NewBlock("map", "$ssl_preread_server_name", variable).Append(ast.New("hostnames"))
+		for _, serverName := range sortedKeys(stream.SNIRoutes) {
+			routes.Append(ast.New(serverName, upstreams[stream.SNIRoutes[serverName]]))
+		}
+		routes.Append(ast.New("default", upstreams[backend]))
+		nodes = append(nodes, routes)
+		backend = variable
+	}
+
+	server := ast.NewBlock("server")
+	listen := ast.New("listen", strconv.Itoa(stream.Listen))
+	switch {
+	case stream.TransportProtocol() == "udp":
+		listen.Args = append(listen.Args, "udp")
+	case stream.TLS:
+		listen.Args = append(listen.Args, "ssl")
+	}
+	server.Append(listen)
+
+	if stream.TLS {
+		server.Append(
+			ast.New("ssl_certificate", sslPath+"/certificate.crt"),
+			ast.New("ssl_certificate_key", sslPath+"/private.key"),
+			ast.New("ssl_protocols", "TLSv1.2", "TLSv1.3"),
+			ast.New("ssl_session_cache", "shared:STREAM_SSL:10m"),
+		)
+	}
+	if len(stream.SNIRoutes) > 0 {
+		server.Append(ast.New("ssl_preread", "on"))
+	}
+	if stream.ConnectTimeout != "" {
+		server.Append(ast.New("proxy_connect_timeout", stream.ConnectTimeout))
+	}
+	if stream.Timeout != "" {
+		server.Append(ast.New("proxy_timeout", stream.Timeout))
+	}
+	server.Append(ast.New("proxy_pass", backend))
+
+	return append(nodes, server)
+}
+
+func streamIdentifier(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+func mapValues(m map[string]string) []string {
+	var values []string
+	for _, key := range sortedKeys(m) {
+		values = append(values, m[key])
+	}
+	return values
+}
@@ -0,0 +1,65 @@
+package nginx
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sinhaparth5/keynginx/internal/config"
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+func GetUpstreamConfig(upstreams []config.UpstreamConfig) []*ast.Directive {
+	if len(upstreams) == 0 {
+		return nil
+	}
+
+	nodes := []*ast.Directive{ast.Comment("Upstreams")}
+	for i, upstream := range upstreams {
+		if i > 0 {
+			nodes = append(nodes, ast.Blank())
+		}
+		block := ast.NewBlock("upstream", upstream.Name)
+
+		switch upstream.Strategy {
+		case "least_conn":
+			block.Append(ast.New("least_conn"))
+		case "ip_hash":
+			block.Append(ast.New("ip_hash"))
+		case "hash":
+			if upstream.Consistent {
+				block.Append(ast.New("hash", upstream.HashKey, "consistent"))
+			} else {
+				block.Append(ast.New("hash", upstream.HashKey))
+			}
+		}
+
+		for _, server := range upstream.Servers {
+			block.Append(ast.New("server", upstreamServerArgs(server)...))
+		}
+
+		if upstream.Keepalive > 0 {
+			block.Append(ast.New("keepalive", strconv.Itoa(upstream.Keepalive)))
+		}
+
+		nodes = append(nodes, block)
+	}
+
+	return nodes
+}
+
+func upstreamServerArgs(server config.UpstreamServer) []string {
+	args := []string{server.Address}
+	if server.Weight > 0 {
+		args = append(args, fmt.Sprintf("weight=%d", server.Weight))
+	}
+	if server.MaxFails > 0 {
+		args = append(args, fmt.Sprintf("max_fails=%d", server.MaxFails))
+	}
+	if server.FailTimeout != "" {
+		args = append(args, "fail_timeout="+server.FailTimeout)
+	}
+	if server.Backup {
+		args = append(args, "backup")
+	}
+	return args
+}
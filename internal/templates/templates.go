@@ -0,0 +1,74 @@
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/sinhaparth5/keynginx/internal/nginx/ast"
+)
+
+const (
+	NginxTemplate   = "nginx.conf.tmpl"
+	ComposeTemplate = "docker-compose.yml.tmpl"
+)
+
+//go:embed *.tmpl
+var builtin embed.FS
+
+// Load parses the built-in templates and then every *.tmpl file in
+// overrideDir, in name order. A file named like a built-in template replaces
+// it entirely; any other file can redefine individual named blocks. A missing
+// overrideDir is not an error.
+func Load(overrideDir string) (*template.Template, error) {
+	tmpl, err := template.New("keynginx").Funcs(Funcs()).ParseFS(builtin, "*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse built-in templates: %w", err)
+	}
+
+	if overrideDir == "" {
+		return tmpl, nil
+	}
+
+	overrides, err := filepath.Glob(filepath.Join(overrideDir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template overrides: %w", err)
+	}
+	sort.Strings(overrides)
+
+	for _, path := range overrides {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template override: %w", err)
+		}
+		if _, err := tmpl.New(filepath.Base(path)).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("failed to parse template override %s: %w", path, err)
+		}
+	}
+
+	return tmpl, nil
+}
+
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"indent": indent,
+		"quote":  ast.Quote,
+	}
+}
+
+// indent prefixes every non-empty line of text with n spaces and drops the
+// trailing newline, so YAML block scalars can be dropped into nested blocks.
+func indent(n int, text string) string {
+	prefix := strings.Repeat(" ", n)
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
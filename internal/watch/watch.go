@@ -0,0 +1,119 @@
+// Package watch reports changes to project files in debounced batches, so
+// an editor saving several files, or writing one in several steps, causes a
+// single regeneration.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Watcher watches files and directories. A watched directory reports
+// changes to the files directly inside it; a watched path that does not
+// exist yet is reported when it is created.
+type Watcher struct {
+	changes chan []string
+	errors  chan error
+	events  chan string
+	done    chan struct{}
+	once    sync.Once
+	backend backend
+}
+
+// backend delivers the path of every changed file to events until closed.
+type backend interface {
+	close() error
+}
+
+// New starts watching paths. Changes are delivered once no further change
+// has been seen for the debounce period.
+func New(paths []string, debounce time.Duration) (*Watcher, error) {
+	w := &Watcher{
+		changes: make(chan []string),
+		errors:  make(chan error, 1),
+		events:  make(chan string, 64),
+		done:    make(chan struct{}),
+	}
+
+	var absolute []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		absolute = append(absolute, abs)
+	}
+
+	b, err := newBackend(absolute, w.events, w.errors, w.done)
+	if err != nil {
+		return nil, err
+	}
+	w.backend = b
+
+	go w.debounce(debounce)
+	return w, nil
+}
+
+// Changes delivers the sorted paths changed since the previous batch.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Errors delivers errors from the underlying notification mechanism.
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.backend.close()
+	})
+	return err
+}
+
+func (w *Watcher) debounce(period time.Duration) {
+	pending := map[string]bool{}
+	timer := time.NewTimer(period)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case path := <-w.events:
+			pending[path] = true
+			timer.Reset(period)
+		case <-timer.C:
+			batch := make([]string, 0, len(pending))
+			for path := range pending {
+				batch = append(batch, path)
+			}
+			sort.Strings(batch)
+			pending = map[string]bool{}
+
+			select {
+			case w.changes <- batch:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+func send(events chan<- string, done <-chan struct{}, path string) {
+	select {
+	case events <- path:
+	case <-done:
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,144 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_MODIFY | unix.IN_CREATE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// inotifyBackend watches directories rather than files: editors often save
+// by writing a new file and renaming it over the old one, which a watch on
+// the file itself would lose.
+type inotifyBackend struct {
+	file *os.File
+	fd   int
+
+	mu sync.Mutex
+	// dirs maps watch descriptors to directories.
+	dirs map[int]string
+	// names holds the entries of interest per directory; "" means all.
+	names map[string]map[string]bool
+	// pending are watched directories that did not exist when added.
+	pending map[string]bool
+}
+
+func newBackend(paths []string, events chan<- string, errs chan<- error, done <-chan struct{}) (backend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	b := &inotifyBackend{
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		fd:      fd,
+		dirs:    map[int]string{},
+		names:   map[string]map[string]bool{},
+		pending: map[string]bool{},
+	}
+
+	for _, path := range paths {
+		if err := b.add(path); err != nil {
+			b.file.Close()
+			return nil, err
+		}
+	}
+
+	go b.read(events, errs, done)
+	return b, nil
+}
+
+// add watches a directory for all of its entries, or the parent of a file
+// or missing path for that entry only.
+func (b *inotifyBackend) add(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if isDir(path) {
+		delete(b.pending, path)
+		return b.watch(path, "")
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		b.pending[path] = true
+	}
+	return b.watch(filepath.Dir(path), filepath.Base(path))
+}
+
+func (b *inotifyBackend) watch(dir, name string) error {
+	if b.names[dir] == nil {
+		wd, err := unix.InotifyAddWatch(b.fd, dir, inotifyMask)
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		b.dirs[wd] = dir
+		b.names[dir] = map[string]bool{}
+	}
+	b.names[dir][name] = true
+	return nil
+}
+
+func (b *inotifyBackend) read(events chan<- string, errs chan<- error, done <-chan struct{}) {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := b.file.Read(buf)
+		if err != nil {
+			select {
+			case <-done:
+			default:
+				errs <- fmt.Errorf("failed to read inotify events: %w", err)
+			}
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			if path, ok := b.match(int(event.Wd), name, event.Mask); ok {
+				send(events, done, path)
+			}
+		}
+	}
+}
+
+// match resolves an event to a watched path. A pending directory that has
+// been created starts being watched itself.
+func (b *inotifyBackend) match(wd int, name string, mask uint32) (string, bool) {
+	b.mu.Lock()
+	dir, ok := b.dirs[wd]
+	if mask&unix.IN_IGNORED != 0 {
+		delete(b.dirs, wd)
+		delete(b.names, dir)
+	}
+	if !ok || name == "" {
+		b.mu.Unlock()
+		return "", false
+	}
+
+	path := filepath.Join(dir, name)
+	names := b.names[dir]
+	watched := names[""] || names[name]
+	pending := b.pending[path]
+	b.mu.Unlock()
+
+	if pending && mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 && isDir(path) {
+		b.add(path)
+	}
+	return path, watched
+}
+
+func (b *inotifyBackend) close() error {
+	return b.file.Close()
+}
@@ -0,0 +1,76 @@
+//go:build !linux
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const pollInterval = 500 * time.Millisecond
+
+// pollBackend compares modification times on platforms without inotify
+// support in this package.
+type pollBackend struct{}
+
+func newBackend(paths []string, events chan<- string, errs chan<- error, done <-chan struct{}) (backend, error) {
+	go func() {
+		previous := snapshot(paths)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			current := snapshot(paths)
+			for path, modified := range current {
+				if previous[path] != modified {
+					send(events, done, path)
+				}
+			}
+			for path := range previous {
+				if _, ok := current[path]; !ok {
+					send(events, done, path)
+				}
+			}
+			previous = current
+		}
+	}()
+	return pollBackend{}, nil
+}
+
+// snapshot records the modification time of every watched file and of the
+// files directly inside watched directories.
+func snapshot(paths []string) map[string]time.Time {
+	files := map[string]time.Time{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files[path] = info.ModTime()
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entryInfo, err := entry.Info(); err == nil {
+				files[filepath.Join(path, entry.Name())] = entryInfo.ModTime()
+			}
+		}
+	}
+	return files
+}
+
+func (pollBackend) close() error {
+	return nil
+}